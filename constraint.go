@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// ConstraintType type of constraint
+type ConstraintType string
+
+// constraint types other than foreign key
+const (
+	ConstraintTypePrimaryKey ConstraintType = "PRIMARY KEY"
+	ConstraintTypeUnique     ConstraintType = "UNIQUE"
+	ConstraintTypeCheck      ConstraintType = "CHECK"
+	ConstraintTypeExclusion  ConstraintType = "EXCLUDE"
+)
+
+var constraintTypes = map[string]ConstraintType{
+	"p": ConstraintTypePrimaryKey,
+	"u": ConstraintTypeUnique,
+	"c": ConstraintTypeCheck,
+	"x": ConstraintTypeExclusion,
+}
+
+// Constraint primary key, unique, check or exclusion constraint
+type Constraint struct {
+	Name        string
+	Type        ConstraintType
+	ColumnNames []string
+	Definition  string
+}
+
+// LoadSchemaConstraintDef load constraints of all tables in schema
+func LoadSchemaConstraintDef(db Queryer, schema string) (map[string][]*Constraint, error) {
+	return LoadSchemaConstraintDefContext(context.Background(), withContext(db), schema)
+}
+
+// LoadSchemaConstraintDefContext load constraints of all tables in schema with context
+func LoadSchemaConstraintDefContext(ctx context.Context, db QueryerContext, schema string) (map[string][]*Constraint, error) {
+	conDefs, err := db.QueryContext(ctx, constraintDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load constraint def")
+	}
+	defer conDefs.Close()
+	cons := make(map[string][]*Constraint)
+	for conDefs.Next() {
+		var (
+			tblName, conType string
+			c                Constraint
+		)
+		err := conDefs.Scan(
+			&tblName,
+			&c.Name,
+			&conType,
+			pq.Array(&c.ColumnNames),
+			&c.Definition,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		c.Type = constraintTypes[conType]
+		cons[tblName] = append(cons[tblName], &c)
+	}
+	if err := conDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load constraint def")
+	}
+	return cons, nil
+}
+
+// SecondaryConstraints constraints other than primary key
+func (t *Table) SecondaryConstraints() []*Constraint {
+	var cons []*Constraint
+	for _, c := range t.Constraints {
+		if c.Type != ConstraintTypePrimaryKey {
+			cons = append(cons, c)
+		}
+	}
+	return cons
+}
+
+// setUniqueKeys set IsUniqueKey of columns unique on their own. Columns of
+// multi-column unique constraints are not unique by themselves, and the
+// constraints are listed in the constraints section instead.
+func setUniqueKeys(t *Table) {
+	for _, con := range t.Constraints {
+		if con.Type != ConstraintTypeUnique || len(con.ColumnNames) != 1 {
+			continue
+		}
+		if c, found := t.FindColumn(con.ColumnNames[0]); found {
+			c.IsUniqueKey = true
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetUniqueKeys(t *testing.T) {
+	customer := testTables(withConstraints)[0]
+	for _, c := range customer.Columns {
+		if expected := c.Name == "name"; c.IsUniqueKey != expected {
+			t.Errorf("%s: want %t got %t", c.Name, expected, c.IsUniqueKey)
+		}
+	}
+	cons := customer.SecondaryConstraints()
+	if len(cons) != 2 || cons[0].Name != "customer_name_key" {
+		t.Errorf("unexpected constraints: %+v", cons)
+	}
+
+	customer.Constraints = append(customer.Constraints, &Constraint{Name: "customer_zip_code_address_key",
+		Type: ConstraintTypeUnique, ColumnNames: []string{"zip_code", "address"}, Definition: "UNIQUE (zip_code, address)"})
+	customer.Columns = append(customer.Columns,
+		&Column{Name: "zip_code", DDLType: "text"}, &Column{Name: "address", DDLType: "text"})
+	setUniqueKeys(customer)
+	for _, c := range customer.Columns {
+		if expected := c.Name == "name"; c.IsUniqueKey != expected {
+			t.Errorf("%s: want %t got %t", c.Name, expected, c.IsUniqueKey)
+		}
+	}
+}
+
+func TestConstraintToPlantUML(t *testing.T) {
+	buf, err := TableToUMLEntry(testTables(withConstraints)[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+entity "**customer**" {
+  Customer Information
+  ..
+  + ""id"": //bigserial [PK]//
+  --
+  *""name"": //text [UK] : Customer Name//
+  *""registered_at"": //timestamp with time zone //
+  ..
+  ""customer_name_key"": //UNIQUE (name)//
+  ""customer_registered_at_check"": //CHECK ((registered_at > '2000-01-01 00:00:00+00'::timestamp with time zone))//
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestConstraintSnapshot(t *testing.T) {
+	tbls := testTables(withConstraints)
+	buf, err := TableToJSON(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer := loaded[0]
+	if !reflect.DeepEqual(customer.Constraints, tbls[0].Constraints) {
+		t.Errorf("want %+v got %+v", tbls[0].Constraints, customer.Constraints)
+	}
+	if !customer.Columns[1].IsUniqueKey {
+		t.Errorf("want unique key: %+v", customer.Columns[1])
+	}
+}
+
+func TestLoadSchemaConstraintDef(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	cons, err := LoadSchemaConstraintDef(conn, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Constraint{
+		{Name: "coupon_pkey", Type: ConstraintTypePrimaryKey, ColumnNames: []string{"id"},
+			Definition: "PRIMARY KEY (id)"},
+		{Name: "coupon_code_key", Type: ConstraintTypeUnique, ColumnNames: []string{"code"},
+			Definition: "UNIQUE (code)"},
+		{Name: "coupon_discount_rate_check", Type: ConstraintTypeCheck, ColumnNames: []string{"discount_rate"},
+			Definition: "CHECK ((discount_rate > (0)::numeric))"},
+	}
+	if !reflect.DeepEqual(cons["coupon"], expected) {
+		t.Errorf("want %+v got %+v", expected, cons["coupon"])
+	}
+
+	tbls, err := LoadTableDef(conn, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coupon, found := FindTableByName(tbls, "coupon")
+	if !found {
+		t.Fatal("coupon not found")
+	}
+	for _, c := range coupon.Columns {
+		if expected := c.Name == "id"; c.IsPrimaryKey != expected {
+			t.Errorf("%s: want primary key %t got %t", c.Name, expected, c.IsPrimaryKey)
+		}
+		if expected := c.Name == "code"; c.IsUniqueKey != expected {
+			t.Errorf("%s: want unique key %t got %t", c.Name, expected, c.IsUniqueKey)
+		}
+	}
+}
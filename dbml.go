@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var dbmlPlainID = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dbmlFuncMap template functions for DBML. Table names are schema
+// qualified like schema.table if tables of multiple schemas are rendered.
+func dbmlFuncMap(multiSchema bool) template.FuncMap {
+	return template.FuncMap{
+		"dbmlTable": func(schema, name string) string {
+			if multiSchema {
+				return dbmlID(schema) + "." + dbmlID(name)
+			}
+			return dbmlID(name)
+		},
+		"dbmlID":         dbmlID,
+		"dbmlString":     dbmlString,
+		"dbmlSettings":   dbmlSettings,
+		"dbmlColumns":    dbmlColumns,
+		"dbmlPrimaryKey": dbmlPrimaryKey,
+	}
+}
+
+// dbmlID double quotes names and types which contain spaces or symbols,
+// e.g. "timestamp with time zone", "numeric(10,2)"
+func dbmlID(s string) string {
+	if dbmlPlainID.MatchString(s) {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dbmlString single quoted string
+func dbmlString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\r\n", `\n`, "\n", `\n`)
+	return `'` + r.Replace(s) + `'`
+}
+
+// dbmlColumns column or composite columns of Ref, e.g. (id, customer_order_id)
+func dbmlColumns(names []string) string {
+	var ids []string
+	for _, n := range names {
+		ids = append(ids, dbmlID(n))
+	}
+	if len(ids) == 1 {
+		return ids[0]
+	}
+	return "(" + strings.Join(ids, ", ") + ")"
+}
+
+// dbmlPrimaryKey columns of primary key, e.g. (id, customer_order_id)
+func dbmlPrimaryKey(t *Table) string {
+	var names []string
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			names = append(names, c.Name)
+		}
+	}
+	return dbmlColumns(names)
+}
+
+// dbmlSettings column settings. pk is set only to a single column primary
+// key, since composite primary key is defined in indexes of the table.
+func dbmlSettings(c *Column, compositePK bool) string {
+	var s []string
+	if c.IsPrimaryKey && !compositePK {
+		s = append(s, "pk")
+	}
+	if c.NotNull {
+		s = append(s, "not null")
+	}
+	if c.Comment.Valid {
+		s = append(s, "note: "+dbmlString(c.Comment.String))
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(s, ", ") + "]"
+}
+
+// TableToDBMLEntry table entry
+func TableToDBMLEntry(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("dbmlEntry").Funcs(dbmlFuncMap(isMultiSchema(tbls))).Parse(dbmlEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, tbl); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToDBMLRelation relation
+func ForeignKeyToDBMLRelation(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("dbmlRelation").Funcs(dbmlFuncMap(isMultiSchema(tbls))).Parse(dbmlRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+// TableToDBML dbdiagram.io/dbdocs DBML
+func TableToDBML(tbls []*Table, title string) ([]byte, error) {
+	entry, err := TableToDBMLEntry(tbls)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := ForeignKeyToDBMLRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	if len(title) != 0 {
+		src = append(src, []byte("Project "+dbmlID(title)+" {\n"+
+			"  database_type: 'PostgreSQL'\n"+
+			"}\n")...)
+	}
+	src = append(src, entry...)
+	src = append(src, rel...)
+	return src, nil
+}
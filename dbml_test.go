@@ -0,0 +1,100 @@
+package main
+
+import "testing"
+
+func TestDBMLID(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{in: "customer", out: "customer"},
+		{in: "timestamp with time zone", out: `"timestamp with time zone"`},
+		{in: "numeric(10,2)", out: `"numeric(10,2)"`},
+	}
+	for _, c := range cases {
+		if got := dbmlID(c.in); got != c.out {
+			t.Errorf("want %s got %s", c.out, got)
+		}
+	}
+}
+
+func TestTableToDBML(t *testing.T) {
+	tbls := testTables()
+	tbls[0].Columns[1].Comment.String = "Customer's Name"
+	buf, err := TableToDBML(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+Table customer {
+  id bigserial [pk, not null]
+  name text [not null, note: 'Customer\'s Name']
+  registered_at "timestamp with time zone" [not null]
+
+  Note: 'Customer Information'
+}
+
+Table customer_order {
+  id bigserial [pk, not null]
+  customer_id bigint [not null]
+}
+
+Table order_detail {
+  id bigserial [not null]
+  customer_order_id bigint [not null]
+  amount bigint [not null]
+
+  indexes {
+    (id, customer_order_id) [pk]
+  }
+}
+
+Table order_detail_approval {
+  order_detail_id bigint [not null]
+  customer_order_id bigint [not null]
+
+  indexes {
+    (order_detail_id, customer_order_id) [pk]
+  }
+}
+
+Ref: customer_order.customer_id > customer.id
+
+Ref: order_detail.customer_order_id > customer_order.id
+
+Ref: order_detail_approval.(order_detail_id, customer_order_id) - order_detail.(id, customer_order_id)
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestTableToDBMLMultiSchema(t *testing.T) {
+	tbls := FilterTables(true, testTables(withSchemas), []string{"^customer$", "invoice"})
+	buf, err := TableToDBML(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+Table public.customer {
+  id bigserial [pk, not null]
+  name text [not null, note: 'Customer Name']
+  registered_at "timestamp with time zone" [not null]
+
+  Note: 'Customer Information'
+}
+
+Table billing.customer {
+  id bigint [pk, not null]
+}
+
+Table billing.invoice {
+  id bigint [pk, not null]
+  customer_id bigint [not null]
+}
+
+Ref: billing.invoice.customer_id > public.customer.id
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Change kind of change in diff
+type Change string
+
+// kinds of change
+const (
+	ChangeAdded   Change = "added"
+	ChangeRemoved Change = "removed"
+	ChangeChanged Change = "changed"
+)
+
+var changeMarks = map[Change]string{
+	ChangeAdded:   "+",
+	ChangeRemoved: "-",
+	ChangeChanged: "~",
+}
+
+// Diff differences between two sets of tables
+type Diff struct {
+	Tables []*TableDiff `json:"tables"`
+}
+
+// IsEmpty returns true if there are no differences
+func (d *Diff) IsEmpty() bool {
+	return len(d.Tables) == 0
+}
+
+// TableDiff added, removed or changed table. Fields, Columns and ForeignKeys
+// are set only to changed tables.
+type TableDiff struct {
+	Schema      string            `json:"schema"`
+	Name        string            `json:"name"`
+	Change      Change            `json:"change"`
+	Fields      []*FieldChange    `json:"fields,omitempty"`
+	Columns     []*ColumnDiff     `json:"columns,omitempty"`
+	ForeignKeys []*ForeignKeyDiff `json:"foreign_keys,omitempty"`
+}
+
+// ColumnDiff added, removed or changed column. Fields of added or removed
+// column have only new or old values.
+type ColumnDiff struct {
+	Name   string         `json:"name"`
+	Change Change         `json:"change"`
+	Fields []*FieldChange `json:"fields"`
+}
+
+// ForeignKeyDiff added, removed or changed foreign key
+type ForeignKeyDiff struct {
+	Name   string         `json:"name"`
+	Change Change         `json:"change"`
+	Fields []*FieldChange `json:"fields"`
+}
+
+// FieldChange old and new value of a field
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// field is a name and value compared by diff
+type field struct {
+	name  string
+	value string
+}
+
+func tableFields(t *Table) []field {
+	return []field{
+		{"kind", string(t.Kind)},
+		{"comment", t.Comment.String},
+	}
+}
+
+func columnFields(c *Column) []field {
+	return []field{
+		{"type", c.DDLType},
+		{"not_null", strconv.FormatBool(c.NotNull)},
+		{"primary_key", strconv.FormatBool(c.IsPrimaryKey)},
+		{"default", c.DefaultClause()},
+		{"comment", c.Comment.String},
+	}
+}
+
+func foreignKeyFields(k *ForeignKey) []field {
+	return []field{
+		{"definition", foreignKeyDefinition(k)},
+	}
+}
+
+// foreignKeyDefinition definition of fk like "(a) REFERENCES public.t(id)"
+func foreignKeyDefinition(k *ForeignKey) string {
+	s := fmt.Sprintf("(%s) REFERENCES %s.%s(%s)",
+		strings.Join(k.SourceColNames(), ", "), k.TargetSchema, k.TargetTableName,
+		strings.Join(k.TargetColNames(), ", "))
+	if a := k.ActionLabel(); a != "" {
+		s += " " + a
+	}
+	return s
+}
+
+// compareFields changed fields. If before or after is nil, all non empty
+// fields of the other are returned.
+func compareFields(before, after []field) []*FieldChange {
+	var changes []*FieldChange
+	for i := range before {
+		fc := &FieldChange{Field: before[i].name, Old: before[i].value}
+		if after != nil {
+			fc.New = after[i].value
+		}
+		if fc.Old != fc.New {
+			changes = append(changes, fc)
+		}
+	}
+	for i := range after {
+		if before == nil && after[i].value != "" {
+			changes = append(changes, &FieldChange{Field: after[i].name, New: after[i].value})
+		}
+	}
+	return changes
+}
+
+// DiffTables compare tables. Tables are matched by schema and name, columns
+// by name and foreign keys by constraint name.
+func DiffTables(before, after []*Table) *Diff {
+	d := &Diff{Tables: []*TableDiff{}}
+	for _, nt := range after {
+		ot, found := FindTableBySchemaName(before, nt.Schema, nt.Name)
+		if !found {
+			d.Tables = append(d.Tables, &TableDiff{Schema: nt.Schema, Name: nt.Name, Change: ChangeAdded})
+			continue
+		}
+		if td := diffTable(ot, nt); td != nil {
+			d.Tables = append(d.Tables, td)
+		}
+	}
+	for _, ot := range before {
+		if _, found := FindTableBySchemaName(after, ot.Schema, ot.Name); !found {
+			d.Tables = append(d.Tables, &TableDiff{Schema: ot.Schema, Name: ot.Name, Change: ChangeRemoved})
+		}
+	}
+	sort.SliceStable(d.Tables, func(i, j int) bool {
+		if d.Tables[i].Schema != d.Tables[j].Schema {
+			return d.Tables[i].Schema < d.Tables[j].Schema
+		}
+		return d.Tables[i].Name < d.Tables[j].Name
+	})
+	return d
+}
+
+func diffTable(before, after *Table) *TableDiff {
+	td := &TableDiff{
+		Schema: after.Schema,
+		Name:   after.Name,
+		Change: ChangeChanged,
+		Fields: compareFields(tableFields(before), tableFields(after)),
+	}
+	for _, nc := range after.Columns {
+		oc, found := before.FindColumn(nc.Name)
+		if !found {
+			td.Columns = append(td.Columns, &ColumnDiff{
+				Name: nc.Name, Change: ChangeAdded, Fields: compareFields(nil, columnFields(nc))})
+			continue
+		}
+		if fs := compareFields(columnFields(oc), columnFields(nc)); len(fs) != 0 {
+			td.Columns = append(td.Columns, &ColumnDiff{Name: nc.Name, Change: ChangeChanged, Fields: fs})
+		}
+	}
+	for _, oc := range before.Columns {
+		if _, found := after.FindColumn(oc.Name); !found {
+			td.Columns = append(td.Columns, &ColumnDiff{
+				Name: oc.Name, Change: ChangeRemoved, Fields: compareFields(columnFields(oc), nil)})
+		}
+	}
+	for _, nfk := range after.ForeingKeys {
+		ofk, found := findForeignKey(before.ForeingKeys, nfk.ConstraintName)
+		if !found {
+			td.ForeignKeys = append(td.ForeignKeys, &ForeignKeyDiff{
+				Name: nfk.ConstraintName, Change: ChangeAdded, Fields: compareFields(nil, foreignKeyFields(nfk))})
+			continue
+		}
+		if fs := compareFields(foreignKeyFields(ofk), foreignKeyFields(nfk)); len(fs) != 0 {
+			td.ForeignKeys = append(td.ForeignKeys, &ForeignKeyDiff{Name: nfk.ConstraintName, Change: ChangeChanged, Fields: fs})
+		}
+	}
+	for _, ofk := range before.ForeingKeys {
+		if _, found := findForeignKey(after.ForeingKeys, ofk.ConstraintName); !found {
+			td.ForeignKeys = append(td.ForeignKeys, &ForeignKeyDiff{
+				Name: ofk.ConstraintName, Change: ChangeRemoved, Fields: compareFields(foreignKeyFields(ofk), nil)})
+		}
+	}
+	if len(td.Fields) == 0 && len(td.Columns) == 0 && len(td.ForeignKeys) == 0 {
+		return nil
+	}
+	return td
+}
+
+func findForeignKey(fks []*ForeignKey, name string) (*ForeignKey, bool) {
+	for _, fk := range fks {
+		if fk.ConstraintName == name {
+			return fk, true
+		}
+	}
+	return nil, false
+}
+
+// formatFields format field changes. Only new or old values are shown for
+// added or removed objects.
+func formatFields(c Change, fs []*FieldChange) string {
+	var s []string
+	for _, f := range fs {
+		switch c {
+		case ChangeAdded:
+			s = append(s, fmt.Sprintf("%s %q", f.Field, f.New))
+		case ChangeRemoved:
+			s = append(s, fmt.Sprintf("%s %q", f.Field, f.Old))
+		default:
+			s = append(s, fmt.Sprintf("%s %q -> %q", f.Field, f.Old, f.New))
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+// DiffToText human readable diff report
+func DiffToText(d *Diff) []byte {
+	buf := new(bytes.Buffer)
+	for _, td := range d.Tables {
+		fmt.Fprintf(buf, "%s table %s.%s\n", changeMarks[td.Change], td.Schema, td.Name)
+		if len(td.Fields) != 0 {
+			fmt.Fprintf(buf, "    %s\n", formatFields(td.Change, td.Fields))
+		}
+		for _, cd := range td.Columns {
+			fmt.Fprintf(buf, "  %s column %s: %s\n", changeMarks[cd.Change], cd.Name, formatFields(cd.Change, cd.Fields))
+		}
+		for _, fd := range td.ForeignKeys {
+			fmt.Fprintf(buf, "  %s foreign key %s: %s\n", changeMarks[fd.Change], fd.Name, formatFields(fd.Change, fd.Fields))
+		}
+	}
+	return buf.Bytes()
+}
+
+// DiffToJSON machine readable diff
+func DiffToJSON(d *Diff) ([]byte, error) {
+	src, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal json")
+	}
+	return append(src, '\n'), nil
+}
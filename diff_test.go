@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffTables(t *testing.T) {
+	d := DiffTables(testTables(), testTables())
+	if !d.IsEmpty() {
+		t.Errorf("want empty diff got %s", DiffToText(d))
+	}
+
+	d = DiffTables(testTables(), testTables(withChanges))
+	expected := `~ table public.customer
+    comment "Customer Information" -> "Customers"
+  ~ column name: type "text" -> "character varying(100)"
+  + column email: type "text", not_null "true", primary_key "false"
+  - column registered_at: type "timestamp with time zone", not_null "true", primary_key "false"
+~ table public.customer_order
+  ~ foreign key customer_order_customer_id_fkey: definition "(customer_id) REFERENCES public.customer(id)" -> "(customer_id) REFERENCES public.customer(id) ON DELETE CASCADE"
++ table public.invoice
+- table public.order_detail_approval
+`
+	if got := string(DiffToText(d)); got != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestDiffToJSON(t *testing.T) {
+	buf, err := DiffToJSON(DiffTables(testTables(), testTables(withChanges)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Diff
+	if err := json.Unmarshal(buf, &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Tables) != 4 {
+		t.Fatalf("want %d got %d\n%s", 4, len(d.Tables), buf)
+	}
+	col := d.Tables[0].Columns[0]
+	if col.Change != ChangeChanged || col.Fields[0].Field != "type" || col.Fields[0].New != "character varying(100)" {
+		t.Errorf("unexpected column diff %+v", col)
+	}
+	if d.Tables[2].Change != ChangeAdded || d.Tables[3].Change != ChangeRemoved {
+		t.Errorf("unexpected table diffs\n%s", buf)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// dotFuncMap template functions for graphviz
+func dotFuncMap(multiSchema bool) template.FuncMap {
+	return template.FuncMap{
+		"entityName":   entityName(multiSchema),
+		"dotID":        dotID,
+		"dotPort":      dotPort,
+		"dotArrowTail": func(k *ForeignKey) string { s, _ := k.Cardinality(); return dotArrows[s] },
+		"dotArrowHead": func(k *ForeignKey) string { _, t := k.Cardinality(); return dotArrows[t] },
+	}
+}
+
+// dotArrows arrow shapes of cardinality, the first shape is drawn next to the node
+var dotArrows = map[Cardinality]string{
+	CardinalityZeroOrOne:  "teeodot",
+	CardinalityExactlyOne: "teetee",
+	CardinalityZeroOrMore: "crowodot",
+	CardinalityOneOrMore:  "crowtee",
+}
+
+// dotID quotes graphviz ID so that any table or column name can be used
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dotPort port of column in table node, e.g. :"id". Boundary tables have no
+// column ports, so edges are connected to the node.
+func dotPort(tbl *Table, col string) string {
+	if tbl != nil && tbl.Boundary {
+		return ""
+	}
+	return ":" + dotID(col)
+}
+
+// TableToDotNode table node. Boundary tables are collapsed in grey.
+func TableToDotNode(tbls []*Table) ([]byte, error) {
+	multiSchema := isMultiSchema(tbls)
+	tpl, err := template.New("dotEntry").Funcs(dotFuncMap(multiSchema)).Parse(dotEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	btpl, err := template.New("dotBoundary").Funcs(dotFuncMap(multiSchema)).Parse(dotBoundaryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		t := tpl
+		if tbl.Boundary {
+			t = btpl
+		}
+		if err := t.Execute(buf, tbl); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToDotEdge relation edge labeled in label mode
+func ForeignKeyToDotEdge(tbls []*Table, label string) ([]byte, error) {
+	tpl, err := template.New("dotRelation").Funcs(dotFuncMap(isMultiSchema(tbls))).
+		Funcs(template.FuncMap{"relationLabel": relationLabel(label)}).Parse(dotRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+// TableToDot graphviz digraph with relationships labeled in label mode
+func TableToDot(tbls []*Table, title, label string) ([]byte, error) {
+	node, err := TableToDotNode(tbls)
+	if err != nil {
+		return nil, err
+	}
+	edge, err := ForeignKeyToDotEdge(tbls, label)
+	if err != nil {
+		return nil, err
+	}
+	src := []byte("digraph planter {\n")
+	src = append(src, []byte("  graph [rankdir=LR")...)
+	if len(title) != 0 {
+		src = append(src, []byte(", labelloc=t, label="+dotID(title))...)
+	}
+	src = append(src, []byte("];\n"+
+		"  node [shape=plaintext];\n"+
+		"  edge [dir=both];\n")...)
+	src = append(src, node...)
+	src = append(src, edge...)
+	src = append(src, []byte("}\n")...)
+	return src, nil
+}
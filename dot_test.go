@@ -0,0 +1,95 @@
+package main
+
+import "testing"
+
+func TestDotID(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{in: "customer", out: `"customer"`},
+		{in: `a"b`, out: `"a\"b"`},
+		{in: `a\b`, out: `"a\\b"`},
+	}
+	for _, c := range cases {
+		if got := dotID(c.in); got != c.out {
+			t.Errorf("want %s got %s", c.out, got)
+		}
+	}
+}
+
+func TestTableToDot(t *testing.T) {
+	tbls := testTables()
+	tbls[0].Comment.String = "Customer <Information>"
+	buf, err := TableToDot(tbls[:2], "title", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph planter {
+  graph [rankdir=LR, labelloc=t, label="title"];
+  node [shape=plaintext];
+  edge [dir=both];
+
+  "customer" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>customer</b></td></tr>
+      <tr><td><i>Customer &lt;Information&gt;</i></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigserial [PK]</td></tr>
+      <tr><td port="name" align="left">name* : text : Customer Name</td></tr>
+      <tr><td port="registered_at" align="left">registered_at* : timestamp with time zone</td></tr>
+    </table>>];
+
+  "customer_order" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>customer_order</b></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigserial [PK]</td></tr>
+      <tr><td port="customer_id" align="left">customer_id* : bigint [FK]</td></tr>
+    </table>>];
+
+  "customer_order":"customer_id" -> "customer":"id" [arrowtail=crowodot, arrowhead=teetee];
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestTableToDotMultiSchema(t *testing.T) {
+	tbls := FilterTables(true, testTables(withSchemas), []string{"^customer$", "invoice"})
+	buf, err := TableToDot(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph planter {
+  graph [rankdir=LR];
+  node [shape=plaintext];
+  edge [dir=both];
+
+  "public.customer" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>public.customer</b></td></tr>
+      <tr><td><i>Customer Information</i></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigserial [PK]</td></tr>
+      <tr><td port="name" align="left">name* : text : Customer Name</td></tr>
+      <tr><td port="registered_at" align="left">registered_at* : timestamp with time zone</td></tr>
+    </table>>];
+
+  "billing.customer" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>billing.customer</b></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigint [PK]</td></tr>
+    </table>>];
+
+  "billing.invoice" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>billing.invoice</b></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigint [PK]</td></tr>
+      <tr><td port="customer_id" align="left">customer_id* : bigint [FK]</td></tr>
+    </table>>];
+
+  "billing.invoice":"customer_id" -> "public.customer":"id" [arrowtail=crowodot, arrowhead=teetee];
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
@@ -1,11 +1,19 @@
+drop view if exists customer_order_count;
+drop materialized view if exists sku_sales;
+drop table if exists shipment;
 drop table if exists order_detail_approval;
 drop table if exists order_detail;
+drop table if exists payment;
 drop table if exists customer_order;
 drop table if exists sku;
 drop table if exists product;
 drop table if exists vendor_address;
 drop table if exists vendor;
 drop table if exists customer;
+drop table if exists coupon;
+drop table if exists delivery_event;
+drop type if exists order_status;
+drop domain if exists price;
 
 
 create table customer (
@@ -90,4 +98,65 @@ create table order_detail_approval (
   , approved_at timestamp with time zone not null
   , PRIMARY KEY(order_detail_id, customer_order_id)
   , FOREIGN KEY(order_detail_id, customer_order_id) REFERENCES order_detail (id, customer_order_id)
-)
+);
+
+create table coupon (
+  id bigserial primary key
+  , code text not null unique
+  , discount_rate numeric not null check (discount_rate > 0)
+);
+
+create view customer_order_count as
+select c.id as customer_id, count(o.id) as order_count
+from customer c left join customer_order o on o.customer_id = c.id
+group by c.id;
+COMMENT ON VIEW customer_order_count IS 'Number of orders by customer';
+
+create materialized view sku_sales as
+select d.sku_id, sum(d.amount) as amount
+from order_detail d
+group by d.sku_id;
+
+create table delivery_event (
+  id bigint not null
+  , customer_order_id bigint not null
+  , occurred_on date not null
+) partition by range (occurred_on);
+
+create table delivery_event_2024_01 partition of delivery_event
+  for values from ('2024-01-01') to ('2024-02-01');
+
+create table delivery_event_2024_02 partition of delivery_event
+  for values from ('2024-02-01') to ('2024-03-01');
+
+create type order_status as enum ('ordered', 'shipped', 'delivered');
+COMMENT ON TYPE order_status IS 'Status of order';
+
+create domain price as numeric not null check (value >= 0);
+
+create table shipment (
+  id bigserial primary key
+  , customer_order_id bigint not null
+  , status order_status not null
+  , status_history order_status[] not null
+  , fee price
+  , FOREIGN KEY(customer_order_id) REFERENCES customer_order (id)
+);
+
+create index customer_order_customer_id_idx on customer_order (customer_id) include (ordered_at);
+create index customer_order_express_idx on customer_order (lower(shipping_address))
+  where delivery_method = 'express';
+
+create table payment (
+  id bigint generated always as identity primary key
+  , payment_key uuid not null default gen_random_uuid()
+  , customer_order_id bigint not null
+  , amount numeric not null
+  , tax_amount numeric generated always as (amount * 0.1) stored
+  , paid_at timestamp with time zone not null default now()
+  , coupon_id bigint
+  , FOREIGN KEY(customer_order_id) REFERENCES customer_order (id) ON DELETE CASCADE
+);
+
+alter table payment add constraint payment_coupon_id_fkey
+  foreign key (coupon_id) references coupon (id) deferrable initially deferred not valid;
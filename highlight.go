@@ -0,0 +1,123 @@
+package main
+
+import "github.com/pkg/errors"
+
+// colors of changes in PlantUML diagram
+var (
+	changeTableColors = map[Change]string{
+		ChangeAdded:   "#palegreen",
+		ChangeRemoved: "#lightcoral",
+		ChangeChanged: "#orange",
+	}
+	changeLineColors = map[Change]string{
+		ChangeAdded:   "#green",
+		ChangeRemoved: "#red",
+		ChangeChanged: "#orange",
+	}
+)
+
+// HighlightChanges set change status of tables, columns and foreign keys in
+// after by comparing them with before. Removed columns and foreign keys are
+// added to their tables, and removed tables are appended to the result.
+// Foreign keys taken from before are resolved against the result.
+func HighlightChanges(before, after []*Table) ([]*Table, error) {
+	tbls := after
+	var removed []*ForeignKey
+	for _, td := range DiffTables(before, after).Tables {
+		switch td.Change {
+		case ChangeAdded:
+			t, _ := FindTableBySchemaName(after, td.Schema, td.Name)
+			setChange(t, ChangeAdded)
+		case ChangeRemoved:
+			t, _ := FindTableBySchemaName(before, td.Schema, td.Name)
+			setChange(t, ChangeRemoved)
+			tbls = append(tbls, t)
+			removed = append(removed, t.ForeingKeys...)
+		default:
+			ot, _ := FindTableBySchemaName(before, td.Schema, td.Name)
+			t, _ := FindTableBySchemaName(after, td.Schema, td.Name)
+			t.Change = ChangeChanged
+			for _, cd := range td.Columns {
+				if cd.Change == ChangeRemoved {
+					c, _ := ot.FindColumn(cd.Name)
+					c.Change = ChangeRemoved
+					t.Columns = append(t.Columns, c)
+					continue
+				}
+				c, _ := t.FindColumn(cd.Name)
+				c.Change = cd.Change
+			}
+			for _, fd := range td.ForeignKeys {
+				if fd.Change == ChangeRemoved {
+					fk, _ := findForeignKey(ot.ForeingKeys, fd.Name)
+					fk.Change = ChangeRemoved
+					fk.SourceTable = t
+					t.ForeingKeys = append(t.ForeingKeys, fk)
+					removed = append(removed, fk)
+					continue
+				}
+				fk, _ := findForeignKey(t.ForeingKeys, fd.Name)
+				fk.Change = fd.Change
+			}
+		}
+	}
+
+	// columns referenced by removed fks are not foreign key columns anymore
+	isFk := make(map[*Column]bool)
+	for _, tbl := range tbls {
+		for _, c := range tbl.Columns {
+			isFk[c] = c.IsForeignKey
+		}
+	}
+	err := resolveForeignKeys(tbls, removed)
+	for c, v := range isFk {
+		c.IsForeignKey = v
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve removed fks")
+	}
+	return tbls, nil
+}
+
+func setChange(t *Table, c Change) {
+	t.Change = c
+	for _, col := range t.Columns {
+		col.Change = c
+	}
+	for _, fk := range t.ForeingKeys {
+		fk.Change = c
+	}
+}
+
+// umlTableColor background color of changed table
+func umlTableColor(t *Table) string {
+	if c, ok := changeTableColors[t.Change]; ok {
+		return " " + c
+	}
+	return ""
+}
+
+// umlChangeStart start of colored text of changed column. Removed column is
+// struck through.
+func umlChangeStart(c Change) string {
+	switch c {
+	case "":
+		return ""
+	case ChangeRemoved:
+		return "<color:" + changeLineColors[c] + ">--"
+	default:
+		return "<color:" + changeLineColors[c] + ">"
+	}
+}
+
+// umlChangeEnd end of colored text of changed column
+func umlChangeEnd(c Change) string {
+	switch c {
+	case "":
+		return ""
+	case ChangeRemoved:
+		return "--</color>"
+	default:
+		return "</color>"
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightChanges(t *testing.T) {
+	tbls, err := HighlightChanges(testTables(), testTables(withChanges))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbls) != 5 {
+		t.Fatalf("want %d got %d", 5, len(tbls))
+	}
+	cases := []struct {
+		tbl      *Table
+		expected Change
+	}{
+		{tbl: tbls[0], expected: ChangeChanged},
+		{tbl: tbls[1], expected: ChangeChanged},
+		{tbl: tbls[2], expected: ""},
+		{tbl: tbls[3], expected: ChangeAdded},
+		{tbl: tbls[4], expected: ChangeRemoved},
+	}
+	for _, c := range cases {
+		if c.tbl.Change != c.expected {
+			t.Errorf("%s: want %q got %q", c.tbl.Name, c.expected, c.tbl.Change)
+		}
+	}
+
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**customer**" #orange {
+  Customers
+  ..
+  + ""id"": //bigserial [PK]//
+  --
+  *<color:#orange>""name"": //character varying(100)  : Customer Name//</color>
+  *<color:#green>""email"": //text //</color>
+  *<color:#red>--""registered_at"": //timestamp with time zone //--</color>
+}
+
+entity "**customer_order**" #orange {
+  + ""id"": //bigserial [PK]//
+  --
+  *""customer_id"": //bigint [FK]//
+}
+
+entity "**order_detail**" {
+  + ""id"": //bigserial [PK]//
+  + ""customer_order_id"": //bigint [PK][FK]//
+  --
+  *""amount"": //bigint //
+}
+
+entity "**invoice**" #palegreen {
+  + <color:#green>""id"": //bigint [PK]//</color>
+  --
+}
+
+entity "**order_detail_approval**" #lightcoral {
+  + <color:#red>--""order_detail_id"": //bigint [PK][FK]//--</color>
+  + <color:#red>--""customer_order_id"": //bigint [PK][FK]//--</color>
+  --
+}
+
+"**customer_order**" }o-[#orange]-|| "**customer**" : ON DELETE CASCADE
+
+"**order_detail**" }o--|| "**customer_order**"
+
+"**order_detail_approval**" |o-[#red]|| "**order_detail**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestHighlightChangesExpandTables(t *testing.T) {
+	after := testTables()
+	order := after[1]
+	order.ForeingKeys = nil
+	order.Columns[1].IsForeignKey = false
+	tbls, err := HighlightChanges(testTables(), after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fk := order.ForeingKeys[0]
+	if fk.Change != ChangeRemoved || fk.SourceTable != order || fk.TargetTable != after[0] {
+		t.Fatalf("removed fk is not resolved against after: %+v", fk)
+	}
+	if order.Columns[1].IsForeignKey {
+		t.Errorf("%s is marked as foreign key", order.Columns[1].Name)
+	}
+
+	tbls = ExpandTables(tbls, MatchTables(tbls, []string{"^customer_order$"}), 1, DirectionReferenced)
+	if got := strings.Join(tableNames(tbls), ","); got != "customer,customer_order" {
+		t.Errorf("want customer,customer_order got %s", got)
+	}
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**customer**" #EEEEEE {
+}
+
+entity "**customer_order**" #orange {
+  + ""id"": //bigserial [PK]//
+  --
+  *""customer_id"": //bigint //
+}
+
+"**customer_order**" }o-[#red]-|| "**customer**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/pkg/errors"
+)
+
+// TableToHTML self-contained HTML schema browser. Tables are embedded as
+// JSON snapshot, so the file can be viewed offline.
+func TableToHTML(tbls []*Table, title string) ([]byte, error) {
+	tpl, err := template.New("html").Parse(htmlTmpl)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, NewSnapshot(tbls, title)); err != nil {
+		return nil, errors.Wrap(err, "failed to execute template: html")
+	}
+	return buf.Bytes(), nil
+}
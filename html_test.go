@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableToHTML(t *testing.T) {
+	tbls := testTables()
+	tbls[0].Comment.String = "</script><script>alert(1)</script>"
+	buf, err := TableToHTML(tbls[:1], "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(buf)
+	if !strings.Contains(src, "<title>title</title>") {
+		t.Errorf("title is not set\n%s", src)
+	}
+	// tables are embedded as JSON snapshot with comment escaped
+	expected := `var model = {"version":2,"title":"title","tables":[{"schema":"public","name":"customer","kind":"","comment":"\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e","auto_gen_pk":false,"columns":[{"field_ordinal":1,"name":"id","comment":null,"data_type":"bigint","ddl_type":"bigserial","not_null":true,"is_primary_key":true,"is_foreign_key":false},{"field_ordinal":2,"name":"name","comment":"Customer Name","data_type":"text","ddl_type":"text","not_null":true,"is_primary_key":false,"is_foreign_key":false},{"field_ordinal":3,"name":"registered_at","comment":null,"data_type":"timestamp with time zone","ddl_type":"timestamp with time zone","not_null":true,"is_primary_key":false,"is_foreign_key":false}],"foreign_keys":[]}]};`
+	var model string
+	for _, l := range strings.Split(src, "\n") {
+		if strings.HasPrefix(l, "var model = ") {
+			model = l
+		}
+	}
+	if model != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, model)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Index index of table
+type Index struct {
+	Name string
+	// Columns column names or expressions of index keys
+	Columns   []string
+	IsUnique  bool
+	IsPrimary bool
+	// Predicate WHERE clause of partial index, empty otherwise
+	Predicate string
+	// Method access method like btree, gin or gist
+	Method string
+}
+
+// LoadIndexDef load indexes of tables
+func LoadIndexDef(db Queryer, schemas []string, tbls []*Table) error {
+	return LoadIndexDefContext(context.Background(), withContext(db), schemas, tbls)
+}
+
+// LoadIndexDefContext load indexes of tables with context.
+// Indexes are set to tables in tbls.
+func LoadIndexDefContext(ctx context.Context, db QueryerContext, schemas []string, tbls []*Table) error {
+	for _, schema := range schemas {
+		if err := loadIndexesContext(ctx, db, schema, tbls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadIndexesContext(ctx context.Context, db QueryerContext, schema string, tbls []*Table) error {
+	idxDefs, err := db.QueryContext(ctx, indexDefSQL, schema)
+	if err != nil {
+		return errors.Wrap(err, "failed to load index def")
+	}
+	defer idxDefs.Close()
+	for idxDefs.Next() {
+		var (
+			tblName string
+			idx     Index
+		)
+		err := idxDefs.Scan(
+			&tblName,
+			&idx.Name,
+			pq.Array(&idx.Columns),
+			&idx.IsUnique,
+			&idx.IsPrimary,
+			&idx.Predicate,
+			&idx.Method,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to scan")
+		}
+		if tbl, found := FindTableBySchemaName(tbls, schema, tblName); found {
+			tbl.Indexes = append(tbl.Indexes, &idx)
+		}
+	}
+	if err := idxDefs.Err(); err != nil {
+		return errors.Wrap(err, "failed to load index def")
+	}
+	for _, tbl := range tbls {
+		if tbl.Schema == schema {
+			tbl.IndexesLoaded = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexToPlantUML(t *testing.T) {
+	tbls := testTables()
+	order := tbls[1]
+	order.Indexes = []*Index{
+		{Name: "customer_order_pkey", Columns: []string{"id"}, IsUnique: true, IsPrimary: true, Method: "btree"},
+		{Name: "customer_order_customer_id_idx", Columns: []string{"customer_id", "lower(note)"},
+			Predicate: "customer_id IS NOT NULL", Method: "btree"},
+		{Name: "customer_order_uniq", Columns: []string{"customer_id"}, IsUnique: true, Method: "hash"},
+	}
+	buf, err := TableToUMLEntry(tbls[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+entity "**customer_order**" {
+  + ""id"": //bigserial [PK]//
+  --
+  *""customer_id"": //bigint [FK]//
+  == indexes ==
+  ""customer_order_pkey"": //[PK] btree (id)//
+  ""customer_order_customer_id_idx"": //btree (customer_id, lower(note)) WHERE customer_id IS NOT NULL//
+  ""customer_order_uniq"": //[UNIQUE] hash (customer_id)//
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+
+	buf, err = TableToJSON(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored[1].Indexes, order.Indexes) {
+		t.Errorf("want %+v got %+v", order.Indexes, restored[1].Indexes)
+	}
+}
+
+func TestLoadIndexDef(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	tbls, err := LoadTableDef(conn, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadIndexDef(conn, []string{"public"}, tbls); err != nil {
+		t.Fatal(err)
+	}
+	order, found := FindTableByName(tbls, "customer_order")
+	if !found {
+		t.Fatal("customer_order not found")
+	}
+	if !order.IndexesLoaded {
+		t.Error("indexes are not marked as loaded")
+	}
+	expected := []*Index{
+		{Name: "customer_order_pkey", Columns: []string{"id"}, IsUnique: true, IsPrimary: true, Method: "btree"},
+		{Name: "customer_order_customer_id_idx", Columns: []string{"customer_id"}, Method: "btree"},
+		{Name: "customer_order_express_idx", Columns: []string{"lower(shipping_address)"},
+			Predicate: "delivery_method = 'express'::text", Method: "btree"},
+	}
+	if !reflect.DeepEqual(order.Indexes, expected) {
+		for _, idx := range order.Indexes {
+			t.Logf("%+v", idx)
+		}
+		t.Errorf("unexpected indexes of %s", order.Name)
+	}
+}
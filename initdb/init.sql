@@ -1,3 +1,5 @@
 CREATE DATABASE planter;
 CREATE USER planter;
 CREATE SCHEMA planter AUTHORIZATION planter;
+\c planter
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severity severity of lint issue
+type Severity string
+
+// severities of lint rules. Rules with SeverityOff are not run.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+// LintIssue problem found by lint rule. Column is empty for table issues.
+type LintIssue struct {
+	RuleID   string   `json:"rule_id"`
+	Severity Severity `json:"severity"`
+	Schema   string   `json:"schema"`
+	Table    string   `json:"table"`
+	Column   string   `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Location schema qualified name of table or column of issue
+func (i *LintIssue) Location() string {
+	if i.Column == "" {
+		return i.Schema + "." + i.Table
+	}
+	return i.Schema + "." + i.Table + "." + i.Column
+}
+
+// LintRule lint rule. Check returns issues without rule id and severity,
+// which are set by Lint.
+type LintRule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	Check       func(tbls []*Table) []*LintIssue
+}
+
+// DefaultLintRules built-in lint rules with default severities
+func DefaultLintRules() []*LintRule {
+	return []*LintRule{
+		{
+			ID:          "no-primary-key",
+			Description: "table has no primary key",
+			Severity:    SeverityError,
+			Check:       lintNoPrimaryKey,
+		},
+		{
+			ID:          "unindexed-foreign-key",
+			Description: "foreign key columns are not the leading columns of an index",
+			Severity:    SeverityWarning,
+			Check:       lintUnindexedForeignKey,
+		},
+		{
+			ID:          "foreign-key-type-mismatch",
+			Description: "foreign key column type differs from referenced column type",
+			Severity:    SeverityError,
+			Check:       lintForeignKeyTypeMismatch,
+		},
+		{
+			ID:          "nullable-foreign-key",
+			Description: "foreign key column is nullable",
+			Severity:    SeverityWarning,
+			Check:       lintNullableForeignKey,
+		},
+		{
+			ID:          "orphan-table",
+			Description: "table neither references nor is referenced by other tables",
+			Severity:    SeverityWarning,
+			Check:       lintOrphanTable,
+		},
+		{
+			ID:          "missing-table-comment",
+			Description: "table has no comment",
+			Severity:    SeverityInfo,
+			Check:       lintMissingTableComment,
+		},
+		{
+			ID:          "missing-column-comment",
+			Description: "column has no comment",
+			Severity:    SeverityInfo,
+			Check:       lintMissingColumnComment,
+		},
+	}
+}
+
+// SetSeverities override severities of rules by rule id
+func SetSeverities(rules []*LintRule, severities map[string]string) error {
+	for id, s := range severities {
+		sev := Severity(s)
+		switch sev {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		default:
+			return errors.Errorf("invalid severity of %s: %s", id, s)
+		}
+		found := false
+		for _, r := range rules {
+			if r.ID == id {
+				r.Severity = sev
+				found = true
+			}
+		}
+		if !found {
+			return errors.Errorf("unknown lint rule: %s", id)
+		}
+	}
+	return nil
+}
+
+// Lint run rules over tables
+func Lint(tbls []*Table, rules []*LintRule) []*LintIssue {
+	issues := []*LintIssue{}
+	for _, r := range rules {
+		if r.Severity == SeverityOff {
+			continue
+		}
+		for _, i := range r.Check(tbls) {
+			i.RuleID = r.ID
+			i.Severity = r.Severity
+			issues = append(issues, i)
+		}
+	}
+	return issues
+}
+
+// IsIndexRuleSkipped returns true if unindexed-foreign-key is enabled but
+// skipped for tables whose indexes are not loaded
+func IsIndexRuleSkipped(tbls []*Table, rules []*LintRule) bool {
+	for _, r := range rules {
+		if r.ID != "unindexed-foreign-key" || r.Severity == SeverityOff {
+			continue
+		}
+		for _, t := range tbls {
+			if !t.IndexesLoaded {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasErrors returns true if any issue has error severity
+func HasErrors(issues []*LintIssue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func lintNoPrimaryKey(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		if t.IsView() || t.IsPartition() {
+			continue
+		}
+		hasPK := false
+		for _, c := range t.Columns {
+			hasPK = hasPK || c.IsPrimaryKey
+		}
+		if !hasPK {
+			issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name,
+				Message: "table has no primary key"})
+		}
+	}
+	return issues
+}
+
+// isIndexed returns true if cols are the leading columns of an index of t
+func isIndexed(t *Table, cols []string) bool {
+	for _, idx := range t.Indexes {
+		if len(idx.Columns) >= len(cols) && sameColumns(idx.Columns[:len(cols)], cols) {
+			return true
+		}
+	}
+	return false
+}
+
+func lintUnindexedForeignKey(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		// indexes are unknown, e.g. snapshot taken without --indexes
+		if !t.IndexesLoaded {
+			continue
+		}
+		for _, fk := range t.ForeingKeys {
+			if cols := fk.SourceColNames(); !isIndexed(t, cols) {
+				issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name, Column: strings.Join(cols, ", "),
+					Message: fmt.Sprintf("foreign key %s has no index on (%s)", fk.ConstraintName, strings.Join(cols, ", "))})
+			}
+		}
+	}
+	return issues
+}
+
+func lintForeignKeyTypeMismatch(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			for _, c := range fk.Columns {
+				if c.SourceColumn == nil || c.TargetColumn == nil || c.SourceColumn.DataType == c.TargetColumn.DataType {
+					continue
+				}
+				issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name, Column: c.SourceColName,
+					Message: fmt.Sprintf("%s references %s.%s of type %s",
+						c.SourceColumn.DataType, fk.TargetTableName, c.TargetColName, c.TargetColumn.DataType)})
+			}
+		}
+	}
+	return issues
+}
+
+func lintNullableForeignKey(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			for _, c := range fk.Columns {
+				if c.SourceColumn != nil && !c.SourceColumn.NotNull {
+					issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name, Column: c.SourceColName,
+						Message: fmt.Sprintf("foreign key column of %s is nullable", fk.ConstraintName)})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func lintOrphanTable(tbls []*Table) []*LintIssue {
+	referenced := make(map[*Table]bool)
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			referenced[fk.TargetTable] = true
+		}
+	}
+	var issues []*LintIssue
+	for _, t := range tbls {
+		if t.IsView() || t.IsPartition() || len(t.ForeingKeys) != 0 || referenced[t] {
+			continue
+		}
+		issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name,
+			Message: "table is not related to any other table"})
+	}
+	return issues
+}
+
+func lintMissingTableComment(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		if !t.Comment.Valid {
+			issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name,
+				Message: "table has no comment"})
+		}
+	}
+	return issues
+}
+
+func lintMissingColumnComment(tbls []*Table) []*LintIssue {
+	var issues []*LintIssue
+	for _, t := range tbls {
+		for _, c := range t.Columns {
+			if !c.Comment.Valid {
+				issues = append(issues, &LintIssue{Schema: t.Schema, Table: t.Name, Column: c.Name,
+					Message: "column has no comment"})
+			}
+		}
+	}
+	return issues
+}
+
+// LintToText human readable lint report
+func LintToText(issues []*LintIssue) []byte {
+	buf := new(bytes.Buffer)
+	for _, i := range issues {
+		fmt.Fprintf(buf, "%s: %s: %s [%s]\n", i.Severity, i.Location(), i.Message, i.RuleID)
+	}
+	return buf.Bytes()
+}
+
+// LintToJSON machine readable lint report
+func LintToJSON(issues []*LintIssue) ([]byte, error) {
+	src, err := json.MarshalIndent(struct {
+		Issues []*LintIssue `json:"issues"`
+	}{issues}, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal json")
+	}
+	return append(src, '\n'), nil
+}
+
+// sarifLevels SARIF levels of severities
+var sarifLevels = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "note",
+}
+
+// LintToSARIF SARIF 2.1.0 lint report for code scanning tools
+func LintToSARIF(issues []*LintIssue, rules []*LintRule) ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID                   string  `json:"id"`
+		ShortDescription     message `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	type logicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+	type location struct {
+		LogicalLocations []logicalLocation `json:"logicalLocations"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	var rs []rule
+	for _, r := range rules {
+		if r.Severity == SeverityOff {
+			continue
+		}
+		sr := rule{ID: r.ID, ShortDescription: message{r.Description}}
+		sr.DefaultConfiguration.Level = sarifLevels[r.Severity]
+		rs = append(rs, sr)
+	}
+	results := []result{}
+	for _, i := range issues {
+		kind := "type"
+		if i.Column != "" {
+			kind = "member"
+		}
+		results = append(results, result{
+			RuleID:    i.RuleID,
+			Level:     sarifLevels[i.Severity],
+			Message:   message{i.Message},
+			Locations: []location{{LogicalLocations: []logicalLocation{{i.Location(), kind}}}},
+		})
+	}
+	type driver struct {
+		Name           string `json:"name"`
+		InformationURI string `json:"informationUri"`
+		Rules          []rule `json:"rules"`
+	}
+	type run struct {
+		Tool struct {
+			Driver driver `json:"driver"`
+		} `json:"tool"`
+		Results []result `json:"results"`
+	}
+	r := run{Results: results}
+	r.Tool.Driver = driver{Name: "planter", InformationURI: "https://github.com/achiku/planter", Rules: rs}
+	src, err := json.MarshalIndent(struct {
+		Version string `json:"version"`
+		Schema  string `json:"$schema"`
+		Runs    []run  `json:"runs"`
+	}{"2.1.0", "https://json.schemastore.org/sarif-2.1.0.json", []run{r}}, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal sarif")
+	}
+	return append(src, '\n'), nil
+}
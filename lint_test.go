@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func lintIssueKeys(issues []*LintIssue) []string {
+	var keys []string
+	for _, i := range issues {
+		keys = append(keys, i.RuleID+" "+i.Location())
+	}
+	return keys
+}
+
+func TestLint(t *testing.T) {
+	tbls := testTables()
+	for _, tbl := range tbls {
+		tbl.IndexesLoaded = true
+	}
+	customer, order := tbls[0], tbls[1]
+	order.Indexes = []*Index{{Name: "customer_order_customer_id_idx", Columns: []string{"customer_id"}}}
+	order.Columns[1].DataType = "integer"
+	tbls[3].Columns[0].NotNull = false
+	tbls = append(tbls, &Table{
+		Schema:  "public",
+		Name:    "setting",
+		Comment: customer.Comment,
+		Columns: []*Column{{Name: "key", DataType: "text", Comment: customer.Comment}},
+	})
+
+	rules := DefaultLintRules()
+	if err := SetSeverities(rules, map[string]string{
+		"missing-table-comment":  "off",
+		"missing-column-comment": "off",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	issues := Lint(tbls, rules)
+	expected := []string{
+		"no-primary-key public.setting",
+		"unindexed-foreign-key public.order_detail.customer_order_id",
+		"unindexed-foreign-key public.order_detail_approval.order_detail_id, customer_order_id",
+		"foreign-key-type-mismatch public.customer_order.customer_id",
+		"nullable-foreign-key public.order_detail_approval.order_detail_id",
+		"orphan-table public.setting",
+	}
+	if got := lintIssueKeys(issues); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if !HasErrors(issues) {
+		t.Error("want errors")
+	}
+}
+
+func TestLintIndexedPrefix(t *testing.T) {
+	tbls := testTables()
+	approval := tbls[3]
+	approval.IndexesLoaded = true
+	approval.Indexes = []*Index{{Name: "order_detail_approval_pkey",
+		Columns: []string{"customer_order_id", "order_detail_id", "id"}, IsPrimary: true}}
+	if issues := lintUnindexedForeignKey([]*Table{approval}); len(issues) != 0 {
+		t.Errorf("want no issues got %v", lintIssueKeys(issues))
+	}
+
+	// indexes are unknown
+	if issues := lintUnindexedForeignKey(testTables()); len(issues) != 0 {
+		t.Errorf("want no issues got %v", lintIssueKeys(issues))
+	}
+	rules := DefaultLintRules()
+	if !IsIndexRuleSkipped(testTables(), rules) {
+		t.Error("want skipped")
+	}
+	if err := SetSeverities(rules, map[string]string{"unindexed-foreign-key": "off"}); err != nil {
+		t.Fatal(err)
+	}
+	if IsIndexRuleSkipped(testTables(), rules) {
+		t.Error("want not skipped")
+	}
+}
+
+func TestSetSeverities(t *testing.T) {
+	cases := []struct {
+		severities map[string]string
+		err        string
+	}{
+		{severities: map[string]string{"orphan-table": "error"}},
+		{severities: map[string]string{"orphan-table": "fatal"}, err: "invalid severity of orphan-table: fatal"},
+		{severities: map[string]string{"no-such-rule": "off"}, err: "unknown lint rule: no-such-rule"},
+	}
+	for _, c := range cases {
+		err := SetSeverities(DefaultLintRules(), c.severities)
+		if c.err == "" && err != nil {
+			t.Errorf("%v: %s", c.severities, err)
+		}
+		if c.err != "" && (err == nil || err.Error() != c.err) {
+			t.Errorf("%v: want %s got %v", c.severities, c.err, err)
+		}
+	}
+}
+
+func TestLintToText(t *testing.T) {
+	issues := []*LintIssue{
+		{RuleID: "no-primary-key", Severity: SeverityError, Schema: "public", Table: "setting",
+			Message: "table has no primary key"},
+		{RuleID: "nullable-foreign-key", Severity: SeverityWarning, Schema: "public", Table: "t", Column: "c",
+			Message: "foreign key column of t_c_fkey is nullable"},
+	}
+	expected := `error: public.setting: table has no primary key [no-primary-key]
+warning: public.t.c: foreign key column of t_c_fkey is nullable [nullable-foreign-key]
+`
+	if got := string(LintToText(issues)); got != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, got)
+	}
+	if HasErrors(issues[1:]) {
+		t.Error("want no errors")
+	}
+}
+
+func TestLintToSARIF(t *testing.T) {
+	rules := DefaultLintRules()
+	rules[len(rules)-1].Severity = SeverityOff
+	issues := []*LintIssue{
+		{RuleID: "missing-table-comment", Severity: SeverityInfo, Schema: "public", Table: "t",
+			Message: "table has no comment"},
+	}
+	src, err := LintToSARIF(issues, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					LogicalLocations []struct {
+						FullyQualifiedName string `json:"fullyQualifiedName"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(src, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif: %s", src)
+	}
+	run := log.Runs[0]
+	if n := len(run.Tool.Driver.Rules); n != len(rules)-1 {
+		t.Errorf("want %d rules got %d", len(rules)-1, n)
+	}
+	if len(run.Results) != 1 || run.Results[0].Level != "note" ||
+		run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName != "public.t" {
+		t.Errorf("unexpected results: %s", src)
+	}
+}
@@ -1,64 +1,311 @@
 package main
 
 import (
+	"context"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kingpin"
+	"github.com/pkg/errors"
 )
 
 var (
-	connStr = kingpin.Arg(
+	schemas = kingpin.Flag(
+		"schema", "PostgreSQL schema name, can be repeated or a glob pattern like app_*").Default("public").Short('s').Strings()
+	outFile    = kingpin.Flag("output", "output file path").Short('o').String()
+	views      = kingpin.Flag("views", "include views and materialized views").Bool()
+	partitions = kingpin.Flag("partitions", "include partitions of partitioned tables").Bool()
+	types      = kingpin.Flag("types", "include enum and domain types used by columns").Bool()
+	indexes    = kingpin.Flag("indexes", "list indexes in entities").Bool()
+	timeout    = kingpin.Flag(
+		"timeout", "timeout of loading table definitions, e.g. 30s (0 means no timeout)").Default("0").Duration()
+
+	generateCmd = kingpin.Command("generate", "generate ER diagram").Default()
+	connStr     = generateCmd.Arg(
 		"conn", "PostgreSQL connection string in URL format").Required().String()
-	schema = kingpin.Flag(
-		"schema", "PostgreSQL schema name").Default("public").Short('s').String()
-	outFile     = kingpin.Flag("output", "output file path").Short('o').String()
-	targetTbls  = kingpin.Flag("table", "target tables").Short('t').Strings()
-	xTargetTbls = kingpin.Flag("exclude", "target tables").Short('x').Strings()
-	title = kingpin.Flag("title", "Diagram title").Short('T').String()
+	targetTbls  = generateCmd.Flag("table", "target tables").Short('t').Strings()
+	xTargetTbls = generateCmd.Flag("exclude", "target tables").Short('x').Strings()
+	title       = generateCmd.Flag("title", "Diagram title").Short('T').String()
+	format      = generateCmd.Flag("format", "output format").Short('f').Default("plantuml").Enum(
+		"plantuml", "mermaid", "dot", "dbml", "json", "yaml", "markdown", "html")
+	depth     = generateCmd.Flag("depth", "include tables within N foreign key hops of target tables").Default("0").Int()
+	direction = generateCmd.Flag("direction", "direction to walk foreign keys with --depth: referenced, referencing or both").Default(
+		DirectionBoth).Enum(DirectionReferenced, DirectionReferencing, DirectionBoth)
+	edgeLabel = generateCmd.Flag("edge-label", "label of relationships: none, column or constraint").Default(LabelNone).Enum(
+		LabelNone, LabelColumn, LabelConstraint)
+	baseline = generateCmd.Flag(
+		"baseline", "highlight changes from baseline (plantuml only), PostgreSQL connection string or path to JSON/YAML snapshot").String()
+	check = generateCmd.Flag(
+		"check", "compare generated output with --output without writing it, and exit with 1 if they differ").Bool()
+
+	diffCmd = kingpin.Command("diff", "compare two databases or snapshots")
+	diffOld = diffCmd.Arg(
+		"old", "PostgreSQL connection string or path to JSON/YAML snapshot").Required().String()
+	diffNew = diffCmd.Arg(
+		"new", "PostgreSQL connection string or path to JSON/YAML snapshot").Required().String()
+	diffFormat = diffCmd.Flag("format", "output format").Short('f').Default("text").Enum("text", "json")
+
+	lintCmd = kingpin.Command("lint", "check schema design problems, exit with 1 if errors are found")
+	lintSrc = lintCmd.Arg(
+		"conn", "PostgreSQL connection string or path to JSON/YAML snapshot").Required().String()
+	lintFormat     = lintCmd.Flag("format", "output format").Short('f').Default("text").Enum("text", "json", "sarif")
+	lintSeverities = lintCmd.Flag("severity", "severity of rule, e.g. orphan-table=off (error, warning, info or off)").StringMap()
+	lintConfig     = lintCmd.Flag("config", "YAML file of severities, naming rules and suppressions").Short('c').String()
 )
 
-func main() {
-	kingpin.Parse()
+// loadTables load tables from database
+func loadTables(connStr string, withIndexes bool) ([]*Table, error) {
+	db, err := OpenDB(connStr)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
 
-	db, err := OpenDB(*connStr)
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	// use a single connection so that statement_timeout applies to all queries
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, errors.Wrap(err, "failed to connect")
+	}
+	defer conn.Close()
+	if *timeout > 0 {
+		if err := SetStatementTimeout(ctx, conn, *timeout); err != nil {
+			return nil, err
+		}
+	}
+	ss, err := ListSchemasContext(ctx, conn, *schemas)
+	if err != nil {
+		return nil, err
+	}
+	// columns of views and partitions are loaded with tables at once
+	cols, err := LoadSchemasColumnDefContext(ctx, conn, ss, *views, *partitions)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := LoadSchemasTableDefWithColumnsContext(ctx, conn, ss, cols)
+	if err != nil {
+		return nil, err
+	}
+	if *views {
+		vs, err := LoadViewDefContext(ctx, conn, ss, ts, cols)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, vs...)
+	}
+	if *partitions {
+		ps, err := LoadPartitionDefContext(ctx, conn, ss, ts, cols)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, ps...)
 	}
+	if *types {
+		if _, err := LoadTypeDefContext(ctx, conn, ss, ts); err != nil {
+			return nil, err
+		}
+	}
+	if withIndexes {
+		if err := LoadIndexDefContext(ctx, conn, ss, ts); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
 
-	ts, err := LoadTableDef(db, *schema)
+// isSnapshotFile returns true if src is a path to JSON or YAML snapshot
+func isSnapshotFile(src string) bool {
+	for _, ext := range []string{".json", ".yaml", ".yml"} {
+		if strings.HasSuffix(src, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadModel load tables from snapshot file or database
+func loadModel(src string, withIndexes bool) ([]*Table, error) {
+	if !isSnapshotFile(src) {
+		return loadTables(src, withIndexes)
+	}
+	buf, err := ioutil.ReadFile(src)
 	if err != nil {
-		log.Fatal(err)
+		return nil, errors.Wrapf(err, "failed to read snapshot %s", src)
+	}
+	return LoadSnapshot(buf)
+}
+
+func generate() ([]byte, error) {
+	// only PlantUML renders change status, other formats would show removed
+	// tables, columns and fks as if they existed
+	if *baseline != "" && *format != "plantuml" {
+		return nil, errors.New("--baseline is supported only by plantuml format")
+	}
+	if *depth > 0 && len(*targetTbls) == 0 {
+		return nil, errors.New("--depth requires --table")
+	}
+	ts, err := loadTables(*connStr, *indexes)
+	if err != nil {
+		return nil, err
+	}
+	if *baseline != "" {
+		base, err := loadModel(*baseline, *indexes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load %s", *baseline)
+		}
+		if ts, err = HighlightChanges(base, ts); err != nil {
+			return nil, err
+		}
 	}
 
 	var tbls []*Table
-	if len(*targetTbls) != 0 {
-		tbls = FilterTables(true, ts, *targetTbls)
-	} else {
+	switch {
+	case len(*targetTbls) == 0:
 		tbls = ts
+	case *depth > 0:
+		tbls = ExpandTables(ts, MatchTables(ts, *targetTbls), *depth, *direction)
+	default:
+		tbls = FilterTables(true, ts, *targetTbls)
 	}
 	if len(*xTargetTbls) != 0 {
 		tbls = FilterTables(false, tbls, *xTargetTbls)
 	}
-	entry, err := TableToUMLEntry(tbls)
+
+	switch *format {
+	case "mermaid":
+		return TableToMermaid(tbls, *title, *edgeLabel)
+	case "dot":
+		return TableToDot(tbls, *title, *edgeLabel)
+	case "dbml":
+		return TableToDBML(tbls, *title)
+	case "json":
+		return TableToJSON(tbls, *title)
+	case "yaml":
+		return TableToYAML(tbls, *title)
+	case "markdown":
+		return TableToMarkdown(tbls, *title)
+	case "html":
+		return TableToHTML(tbls, *title)
+	default:
+		return TableToPlantUML(tbls, *title, *edgeLabel)
+	}
+}
+
+func diff() ([]byte, error) {
+	oldTbls, err := loadModel(*diffOld, *indexes)
 	if err != nil {
-		log.Fatal(err)
+		return nil, errors.Wrapf(err, "failed to load %s", *diffOld)
 	}
-	rel, err := ForeignKeyToUMLRelation(tbls)
+	newTbls, err := loadModel(*diffNew, *indexes)
 	if err != nil {
-		log.Fatal(err)
+		return nil, errors.Wrapf(err, "failed to load %s", *diffNew)
+	}
+	d := DiffTables(oldTbls, newTbls)
+	if *diffFormat == "json" {
+		return DiffToJSON(d)
+	}
+	return DiffToText(d), nil
+}
+
+// lint returns report and true if errors are found
+func lint() ([]byte, bool, error) {
+	// indexes are always loaded for unindexed-foreign-key rule
+	tbls, err := loadModel(*lintSrc, true)
+	if err != nil {
+		return nil, false, err
+	}
+	cfg := &LintConfig{}
+	if *lintConfig != "" {
+		buf, err := ioutil.ReadFile(*lintConfig)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to read %s", *lintConfig)
+		}
+		if cfg, err = LoadLintConfig(buf); err != nil {
+			return nil, false, err
+		}
+	}
+	rules, err := cfg.LintRules()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := SetSeverities(rules, *lintSeverities); err != nil {
+		return nil, false, err
+	}
+	if IsIndexRuleSkipped(tbls, rules) {
+		log.Printf("indexes are not in %s, unindexed-foreign-key is skipped. take snapshot with --indexes", *lintSrc)
+	}
+	issues, err := SuppressIssues(Lint(tbls, rules), cfg.Suppressions)
+	if err != nil {
+		return nil, false, err
 	}
 	var src []byte
-	src = append([]byte("@startuml\n"))
-	if len(*title) != 0 {
-		src = append(src, []byte("title " + *title + "\n")...)
+	switch *lintFormat {
+	case "json":
+		src, err = LintToJSON(issues)
+	case "sarif":
+		src, err = LintToSARIF(issues, rules)
+	default:
+		src = LintToText(issues)
+	}
+	return src, HasErrors(issues), err
+}
+
+// checkOutput print unified diff of --output and src, and returns false if
+// they differ
+func checkOutput(src []byte) (bool, error) {
+	if *outFile == "" {
+		return false, errors.New("--check requires --output")
+	}
+	cur, err := ioutil.ReadFile(*outFile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, errors.Wrapf(err, "failed to read %s", *outFile)
+	}
+	d := UnifiedDiff(*outFile, *outFile+" (generated)", cur, src)
+	if len(d) == 0 {
+		return true, nil
+	}
+	if _, err := os.Stdout.Write(d); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func main() {
+	var (
+		src    []byte
+		failed bool
+		err    error
+	)
+	switch kingpin.Parse() {
+	case diffCmd.FullCommand():
+		src, err = diff()
+	case lintCmd.FullCommand():
+		src, failed, err = lint()
+	default:
+		src, err = generate()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *check {
+		ok, err := checkOutput(src)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
 	}
-	src = append(src, []byte("hide circle\n" +
-		"skinparam linetype ortho\n")...)
-	src = append(src, entry...)
-	src = append(src, rel...)
-	src = append(src, []byte("@enduml\n")...)
 
 	var out io.Writer
 	if *outFile != "" {
@@ -72,4 +319,7 @@ func main() {
 	if _, err := out.Write(src); err != nil {
 		log.Fatal(err)
 	}
+	if failed {
+		os.Exit(1)
+	}
 }
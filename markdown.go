@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var markdownAnchorInvalidChars = regexp.MustCompile(`[^a-z0-9 _\-]`)
+
+// markdownFuncMap template functions for markdown
+func markdownFuncMap(multiSchema bool) template.FuncMap {
+	return template.FuncMap{
+		"entityName":      entityName(multiSchema),
+		"markdownCell":    markdownCell,
+		"markdownKeys":    markdownKeys,
+		"markdownAnchor":  markdownAnchor,
+		"markdownColumns": markdownColumns,
+	}
+}
+
+// markdownCell escapes pipes and newlines so that s fits in a table cell
+func markdownCell(s string) string {
+	r := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	return r.Replace(s)
+}
+
+// markdownAnchor anchor generated by GitHub/GitLab for headings
+func markdownAnchor(s string) string {
+	s = markdownAnchorInvalidChars.ReplaceAllString(strings.ToLower(s), "")
+	return strings.ReplaceAll(s, " ", "-")
+}
+
+// markdownColumns column or composite columns in code span
+func markdownColumns(names []string) string {
+	if len(names) == 1 {
+		return "`" + names[0] + "`"
+	}
+	return "`(" + strings.Join(names, ", ") + ")`"
+}
+
+func markdownKeys(c *Column) string {
+	var keys []string
+	if c.IsPrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if c.IsForeignKey {
+		keys = append(keys, "FK")
+	}
+	if c.IsUniqueKey {
+		keys = append(keys, "UK")
+	}
+	return strings.Join(keys, ", ")
+}
+
+// markdownEntry data passed to markdownEntryTmpl
+type markdownEntry struct {
+	Table        *Table
+	ReferencedBy []*ForeignKey
+}
+
+// FindReferencingForeignKeys find foreign keys referencing tbl
+func FindReferencingForeignKeys(tbls []*Table, tbl *Table) []*ForeignKey {
+	var fks []*ForeignKey
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			if fk.TargetTable == tbl {
+				fks = append(fks, fk)
+			}
+		}
+	}
+	return fks
+}
+
+// TableToMarkdownEntry table entry
+func TableToMarkdownEntry(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("markdownEntry").Funcs(markdownFuncMap(isMultiSchema(tbls))).Parse(markdownEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		e := markdownEntry{
+			Table:        tbl,
+			ReferencedBy: FindReferencingForeignKeys(tbls, tbl),
+		}
+		if err := tpl.Execute(buf, e); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// TableToMarkdown markdown data dictionary
+func TableToMarkdown(tbls []*Table, title string) ([]byte, error) {
+	entry, err := TableToMarkdownEntry(tbls)
+	if err != nil {
+		return nil, err
+	}
+	if len(title) == 0 {
+		title = "Data Dictionary"
+	}
+	src := []byte("# " + title + "\n\n")
+	name := entityName(isMultiSchema(tbls))
+	for _, tbl := range tbls {
+		n := name(tbl.Schema, tbl.Name)
+		src = append(src, []byte("- ["+n+"](#"+markdownAnchor(n)+")\n")...)
+	}
+	src = append(src, entry...)
+	return src, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMarkdownAnchor(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{in: "customer_order", out: "customer_order"},
+		{in: "Customer Order", out: "customer-order"},
+		{in: "order.detail", out: "orderdetail"},
+	}
+	for _, c := range cases {
+		if got := markdownAnchor(c.in); got != c.out {
+			t.Errorf("want %s got %s", c.out, got)
+		}
+	}
+}
+
+func TestTableToMarkdown(t *testing.T) {
+	tbls := testTables()
+	tbls[2].Columns[2].Comment = sql.NullString{String: "first | last", Valid: true}
+	buf, err := TableToMarkdown(FilterTables(true, tbls, []string{"order_detail"}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Data Dictionary\n" +
+		"\n" +
+		"- [order_detail](#order_detail)\n" +
+		"- [order_detail_approval](#order_detail_approval)\n" +
+		"\n" +
+		"## order_detail\n" +
+		"\n" +
+		"| Column | Type | Nullable | Key | Comment |\n" +
+		"|--------|------|----------|-----|---------|\n" +
+		"| id | bigserial | NO | PK |  |\n" +
+		"| customer_order_id | bigint | NO | PK, FK |  |\n" +
+		"| amount | bigint | NO |  | first \\| last |\n" +
+		"\n" +
+		"### Referenced by\n" +
+		"\n" +
+		"- [order_detail_approval](#order_detail_approval).`(order_detail_id, customer_order_id)` → `(id, customer_order_id)`\n" +
+		"\n" +
+		"## order_detail_approval\n" +
+		"\n" +
+		"| Column | Type | Nullable | Key | Comment |\n" +
+		"|--------|------|----------|-----|---------|\n" +
+		"| order_detail_id | bigint | NO | PK, FK |  |\n" +
+		"| customer_order_id | bigint | NO | PK, FK |  |\n" +
+		"\n" +
+		"### References\n" +
+		"\n" +
+		"- `(order_detail_id, customer_order_id)` → [order_detail](#order_detail).`(id, customer_order_id)`\n"
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestTableToMarkdownMultiSchema(t *testing.T) {
+	tbls := FilterTables(true, testTables(withSchemas), []string{"^customer$", "invoice"})
+	buf, err := TableToMarkdown(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Data Dictionary\n" +
+		"\n" +
+		"- [public.customer](#publiccustomer)\n" +
+		"- [billing.customer](#billingcustomer)\n" +
+		"- [billing.invoice](#billinginvoice)\n" +
+		"\n" +
+		"## public.customer\n" +
+		"\n" +
+		"Customer Information\n" +
+		"\n" +
+		"| Column | Type | Nullable | Key | Comment |\n" +
+		"|--------|------|----------|-----|---------|\n" +
+		"| id | bigserial | NO | PK |  |\n" +
+		"| name | text | NO |  | Customer Name |\n" +
+		"| registered_at | timestamp with time zone | NO |  |  |\n" +
+		"\n" +
+		"### Referenced by\n" +
+		"\n" +
+		"- [billing.invoice](#billinginvoice).`customer_id` → `id`\n" +
+		"\n" +
+		"## billing.customer\n" +
+		"\n" +
+		"| Column | Type | Nullable | Key | Comment |\n" +
+		"|--------|------|----------|-----|---------|\n" +
+		"| id | bigint | NO | PK |  |\n" +
+		"\n" +
+		"## billing.invoice\n" +
+		"\n" +
+		"| Column | Type | Nullable | Key | Comment |\n" +
+		"|--------|------|----------|-----|---------|\n" +
+		"| id | bigint | NO | PK |  |\n" +
+		"| customer_id | bigint | NO | FK |  |\n" +
+		"\n" +
+		"### References\n" +
+		"\n" +
+		"- `customer_id` → [public.customer](#publiccustomer).`id`\n"
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var mermaidInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_\-\[\]()]`)
+
+// mermaidFuncMap template functions for mermaid
+func mermaidFuncMap(multiSchema bool) template.FuncMap {
+	return template.FuncMap{
+		"entityName":     entityName(multiSchema),
+		"mermaidName":    mermaidName,
+		"mermaidComment": mermaidComment,
+		"mermaidKeys":    mermaidKeys,
+		"mermaidArrow":   mermaidArrow,
+	}
+}
+
+// mermaidArrow crow's foot arrow of relation
+func mermaidArrow(k *ForeignKey) string {
+	source, target := k.Cardinality()
+	return leftCrowsFoot[source] + "--" + rightCrowsFoot[target]
+}
+
+// mermaidName replaces characters mermaid does not accept in entity names,
+// attribute names and attribute types, e.g. "timestamp with time zone"
+func mermaidName(s string) string {
+	return mermaidInvalidChars.ReplaceAllString(s, "_")
+}
+
+// mermaidComment mermaid comments can neither contain double quotes nor newlines
+func mermaidComment(s string) string {
+	r := strings.NewReplacer(`"`, `'`, "\r\n", " ", "\n", " ")
+	return r.Replace(s)
+}
+
+func mermaidKeys(c *Column) string {
+	var keys []string
+	if c.IsPrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if c.IsForeignKey {
+		keys = append(keys, "FK")
+	}
+	if c.IsUniqueKey {
+		keys = append(keys, "UK")
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return " " + strings.Join(keys, ", ")
+}
+
+// TableToMermaidEntry table entry. Boundary tables are rendered without columns.
+func TableToMermaidEntry(tbls []*Table) ([]byte, error) {
+	multiSchema := isMultiSchema(tbls)
+	tpl, err := template.New("mermaidEntry").Funcs(mermaidFuncMap(multiSchema)).Parse(mermaidEntryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	btpl, err := template.New("mermaidBoundary").Funcs(mermaidFuncMap(multiSchema)).Parse(mermaidBoundaryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		buf := new(bytes.Buffer)
+		t := tpl
+		if tbl.Boundary {
+			t = btpl
+		}
+		if err := t.Execute(buf, tbl); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// ForeignKeyToMermaidRelation relation labeled in label mode
+func ForeignKeyToMermaidRelation(tbls []*Table, label string) ([]byte, error) {
+	tpl, err := template.New("mermaidRelation").Funcs(mermaidFuncMap(isMultiSchema(tbls))).
+		Funcs(template.FuncMap{"relationLabel": relationLabel(label)}).Parse(mermaidRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, fk); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", fk.ConstraintName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
+
+// TableToMermaid mermaid erDiagram with relationships labeled in label mode
+func TableToMermaid(tbls []*Table, title, label string) ([]byte, error) {
+	entry, err := TableToMermaidEntry(tbls)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := ForeignKeyToMermaidRelation(tbls, label)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	if len(title) != 0 {
+		src = append(src, []byte("---\ntitle: "+title+"\n---\n")...)
+	}
+	src = append(src, []byte("erDiagram\n")...)
+	src = append(src, entry...)
+	src = append(src, rel...)
+	return src, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestMermaidName(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{in: "customer", out: "customer"},
+		{in: "timestamp with time zone", out: "timestamp_with_time_zone"},
+		{in: "numeric(10,2)", out: "numeric(10_2)"},
+		{in: "text[]", out: "text[]"},
+	}
+	for _, c := range cases {
+		if got := mermaidName(c.in); got != c.out {
+			t.Errorf("want %s got %s", c.out, got)
+		}
+	}
+}
+
+func TestTableToMermaid(t *testing.T) {
+	buf, err := TableToMermaid(testTables()[:2], "title", LabelConstraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `---
+title: title
+---
+erDiagram
+
+  %% Customer Information
+  customer {
+    bigserial id PK
+    text name "Customer Name"
+    timestamp_with_time_zone registered_at
+  }
+
+  customer_order {
+    bigserial id PK
+    bigint customer_id FK
+  }
+
+  customer_order }o--|| customer : "customer_order_customer_id_fkey"
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestTableToMermaidMultiSchema(t *testing.T) {
+	tbls := FilterTables(true, testTables(withSchemas), []string{"^customer$", "invoice"})
+	buf, err := TableToMermaid(tbls, "", LabelConstraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `erDiagram
+
+  %% Customer Information
+  public_customer {
+    bigserial id PK
+    text name "Customer Name"
+    timestamp_with_time_zone registered_at
+  }
+
+  billing_customer {
+    bigint id PK
+  }
+
+  billing_invoice {
+    bigint id PK
+    bigint customer_id FK
+  }
+
+  billing_invoice }o--|| public_customer : "invoice_customer_id_fkey"
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// targets of naming rules
+const (
+	NamingTargetTable            = "table"
+	NamingTargetColumn           = "column"
+	NamingTargetForeignKey       = "foreign-key"
+	NamingTargetForeignKeyColumn = "foreign-key-column"
+	NamingTargetIndex            = "index"
+)
+
+// LintConfig lint configuration file
+type LintConfig struct {
+	Severities   map[string]string `yaml:"severities"`
+	Naming       []*NamingRule     `yaml:"naming"`
+	Suppressions []*Suppression    `yaml:"suppressions"`
+}
+
+// NamingRule naming convention of tables, columns, foreign keys, foreign key
+// columns or indexes. A name must match Pattern, must not match NotPattern,
+// and must be equal to the name rendered by Template.
+type NamingRule struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Target      string   `yaml:"target"`
+	Severity    Severity `yaml:"severity"`
+	Pattern     string   `yaml:"pattern"`
+	NotPattern  string   `yaml:"not_pattern"`
+	Template    string   `yaml:"template"`
+}
+
+// Suppression ignore issues of rules on tables whose whole name matches
+// Table regexp. All rules are suppressed if Rules is empty.
+type Suppression struct {
+	Table string   `yaml:"table"`
+	Rules []string `yaml:"rules"`
+}
+
+// NamingSubject name checked by naming rule with its context. It is also
+// data of Template, e.g. "{{ .TargetTable }}_{{ .TargetColumn }}".
+type NamingSubject struct {
+	Schema       string
+	Table        string
+	Column       string
+	Constraint   string
+	TargetTable  string
+	TargetColumn string
+	Name         string
+}
+
+// LoadLintConfig load lint configuration from YAML
+func LoadLintConfig(src []byte) (*LintConfig, error) {
+	var c LintConfig
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to unmarshal lint config")
+	}
+	return &c, nil
+}
+
+// LintRules built-in rules followed by naming rules, with severities of
+// config applied
+func (c *LintConfig) LintRules() ([]*LintRule, error) {
+	rules := DefaultLintRules()
+	for _, n := range c.Naming {
+		for _, r := range rules {
+			if r.ID == n.ID {
+				return nil, errors.Errorf("duplicated lint rule: %s", n.ID)
+			}
+		}
+		r, err := n.LintRule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	if err := SetSeverities(rules, c.Severities); err != nil {
+		return nil, err
+	}
+	for _, s := range c.Suppressions {
+		for _, id := range s.Rules {
+			found := false
+			for _, r := range rules {
+				found = found || r.ID == id
+			}
+			if !found {
+				return nil, errors.Errorf("unknown lint rule in suppression of %s: %s", s.Table, id)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// LintRule compile naming rule to lint rule
+func (n *NamingRule) LintRule() (*LintRule, error) {
+	if n.ID == "" {
+		return nil, errors.New("naming rule without id")
+	}
+	if n.Pattern == "" && n.NotPattern == "" && n.Template == "" {
+		return nil, errors.Errorf("naming rule %s has no pattern, not_pattern or template", n.ID)
+	}
+	var subjects func([]*Table) []*NamingSubject
+	switch n.Target {
+	case NamingTargetTable:
+		subjects = tableSubjects
+	case NamingTargetColumn:
+		subjects = columnSubjects
+	case NamingTargetForeignKey:
+		subjects = foreignKeySubjects
+	case NamingTargetForeignKeyColumn:
+		subjects = foreignKeyColumnSubjects
+	case NamingTargetIndex:
+		subjects = indexSubjects
+	default:
+		return nil, errors.Errorf("invalid target of naming rule %s: %s", n.ID, n.Target)
+	}
+	sev := n.Severity
+	switch sev {
+	case "":
+		sev = SeverityWarning
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+	default:
+		return nil, errors.Errorf("invalid severity of %s: %s", n.ID, sev)
+	}
+
+	var pattern, notPattern *regexp.Regexp
+	var tmpl *template.Template
+	var err error
+	if n.Pattern != "" {
+		if pattern, err = regexp.Compile(n.Pattern); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern of naming rule %s", n.ID)
+		}
+	}
+	if n.NotPattern != "" {
+		if notPattern, err = regexp.Compile(n.NotPattern); err != nil {
+			return nil, errors.Wrapf(err, "invalid not_pattern of naming rule %s", n.ID)
+		}
+	}
+	if n.Template != "" {
+		if tmpl, err = template.New(n.ID).Option("missingkey=error").Parse(n.Template); err != nil {
+			return nil, errors.Wrapf(err, "invalid template of naming rule %s", n.ID)
+		}
+		// unknown fields are found only when executed
+		if err := tmpl.Execute(ioutil.Discard, &NamingSubject{}); err != nil {
+			return nil, errors.Wrapf(err, "invalid template of naming rule %s", n.ID)
+		}
+	}
+	desc := n.Description
+	if desc == "" {
+		desc = fmt.Sprintf("%s name does not follow naming convention", n.Target)
+	}
+
+	check := func(tbls []*Table) []*LintIssue {
+		var issues []*LintIssue
+		for _, s := range subjects(tbls) {
+			var msg string
+			switch {
+			case pattern != nil && !pattern.MatchString(s.Name):
+				msg = fmt.Sprintf("%s %s does not match %s", n.Target, s.Name, n.Pattern)
+			case notPattern != nil && notPattern.MatchString(s.Name):
+				msg = fmt.Sprintf("%s %s matches %s", n.Target, s.Name, n.NotPattern)
+			case tmpl != nil:
+				buf := new(bytes.Buffer)
+				if err := tmpl.Execute(buf, s); err == nil && buf.String() != s.Name {
+					msg = fmt.Sprintf("%s %s should be named %s", n.Target, s.Name, buf.String())
+				}
+			}
+			if msg != "" {
+				issues = append(issues, &LintIssue{Schema: s.Schema, Table: s.Table, Column: s.Column, Message: msg})
+			}
+		}
+		return issues
+	}
+	return &LintRule{ID: n.ID, Description: desc, Severity: sev, Check: check}, nil
+}
+
+func tableSubjects(tbls []*Table) []*NamingSubject {
+	var ss []*NamingSubject
+	for _, t := range tbls {
+		ss = append(ss, &NamingSubject{Schema: t.Schema, Table: t.Name, Name: t.Name})
+	}
+	return ss
+}
+
+func columnSubjects(tbls []*Table) []*NamingSubject {
+	var ss []*NamingSubject
+	for _, t := range tbls {
+		for _, c := range t.Columns {
+			ss = append(ss, &NamingSubject{Schema: t.Schema, Table: t.Name, Column: c.Name, Name: c.Name})
+		}
+	}
+	return ss
+}
+
+func foreignKeySubjects(tbls []*Table) []*NamingSubject {
+	var ss []*NamingSubject
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			ss = append(ss, &NamingSubject{Schema: t.Schema, Table: t.Name, Constraint: fk.ConstraintName,
+				TargetTable: fk.TargetTableName, Name: fk.ConstraintName})
+		}
+	}
+	return ss
+}
+
+func foreignKeyColumnSubjects(tbls []*Table) []*NamingSubject {
+	var ss []*NamingSubject
+	for _, t := range tbls {
+		for _, fk := range t.ForeingKeys {
+			for _, c := range fk.Columns {
+				ss = append(ss, &NamingSubject{Schema: t.Schema, Table: t.Name, Column: c.SourceColName,
+					Constraint: fk.ConstraintName, TargetTable: fk.TargetTableName, TargetColumn: c.TargetColName,
+					Name: c.SourceColName})
+			}
+		}
+	}
+	return ss
+}
+
+func indexSubjects(tbls []*Table) []*NamingSubject {
+	var ss []*NamingSubject
+	for _, t := range tbls {
+		for _, idx := range t.Indexes {
+			ss = append(ss, &NamingSubject{Schema: t.Schema, Table: t.Name, Constraint: idx.Name, Name: idx.Name})
+		}
+	}
+	return ss
+}
+
+// SuppressIssues issues not suppressed by sups
+func SuppressIssues(issues []*LintIssue, sups []*Suppression) ([]*LintIssue, error) {
+	exps := make([]*regexp.Regexp, len(sups))
+	for i, s := range sups {
+		exp, err := regexp.Compile("^(?:" + s.Table + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid table of suppression %s", s.Table)
+		}
+		exps[i] = exp
+	}
+	kept := []*LintIssue{}
+	for _, i := range issues {
+		suppressed := false
+		for j, s := range sups {
+			if exps[j].MatchString(i.Table) && (len(s.Rules) == 0 || containsString(s.Rules, i.RuleID)) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, i)
+		}
+	}
+	return kept, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testLintConfig = `
+severities:
+  missing-table-comment: "off"
+  missing-column-comment: "off"
+naming:
+  - id: table-singular
+    target: table
+    pattern: ^[a-z][a-z0-9_]*$
+    not_pattern: s$
+  - id: fk-column-name
+    target: foreign-key-column
+    template: "{{ .TargetTable }}_{{ .TargetColumn }}"
+    severity: error
+  - id: fk-prefix
+    target: foreign-key
+    pattern: ^fk_
+  - id: index-prefix
+    target: index
+    pattern: ^idx_
+    severity: info
+suppressions:
+  - table: order_detail_approval
+    rules: [fk-prefix]
+  - table: legacy_.*
+  - table: order
+`
+
+func TestLintNamingRules(t *testing.T) {
+	cfg, err := LoadLintConfig([]byte(testLintConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := cfg.LintRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls := testTables()
+	tbls[1].Indexes = []*Index{{Name: "customer_order_customer_id_idx", Columns: []string{"customer_id"}}}
+	tbls = append(tbls,
+		&Table{Schema: "public", Name: "Orders", Columns: []*Column{{Name: "id", IsPrimaryKey: true}}},
+		&Table{Schema: "public", Name: "legacy_items"},
+	)
+	issues, err := SuppressIssues(Lint(tbls, rules), cfg.Suppressions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, i := range issues {
+		if strings.HasPrefix(i.RuleID, "table-") || strings.HasPrefix(i.RuleID, "fk-") || strings.HasPrefix(i.RuleID, "index-") {
+			got = append(got, string(i.Severity)+" "+i.Location()+": "+i.Message)
+		}
+	}
+	expected := []string{
+		"warning public.Orders: table Orders does not match ^[a-z][a-z0-9_]*$",
+		"error public.order_detail_approval.customer_order_id: foreign-key-column customer_order_id should be named order_detail_customer_order_id",
+		"warning public.customer_order: foreign-key customer_order_customer_id_fkey does not match ^fk_",
+		"warning public.order_detail: foreign-key order_detail_customer_order_id_fkey does not match ^fk_",
+		"info public.customer_order: index customer_order_customer_id_idx does not match ^idx_",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestNamingRuleError(t *testing.T) {
+	cases := []struct {
+		rule *NamingRule
+		err  string
+	}{
+		{rule: &NamingRule{Target: "table", Pattern: "^a"}, err: "naming rule without id"},
+		{rule: &NamingRule{ID: "r", Target: "table"}, err: "naming rule r has no pattern, not_pattern or template"},
+		{rule: &NamingRule{ID: "r", Target: "view", Pattern: "^a"}, err: "invalid target of naming rule r: view"},
+		{rule: &NamingRule{ID: "r", Target: "table", Pattern: "^a", Severity: "fatal"}, err: "invalid severity of r: fatal"},
+		{rule: &NamingRule{ID: "r", Target: "table", Pattern: "("}, err: "invalid pattern of naming rule r"},
+		{rule: &NamingRule{ID: "r", Target: "table", Template: "{{ .Table"}, err: "invalid template of naming rule r"},
+		{rule: &NamingRule{ID: "r", Target: "table", Template: "{{ .TargetTabel }}"}, err: "invalid template of naming rule r"},
+	}
+	for _, c := range cases {
+		_, err := c.rule.LintRule()
+		if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+			t.Errorf("%+v: want %s got %v", c.rule, c.err, err)
+		}
+	}
+}
+
+func TestLoadLintConfigError(t *testing.T) {
+	if _, err := LoadLintConfig([]byte("namings: []\n")); err == nil {
+		t.Error("want error of unknown field")
+	}
+	cfg, err := LoadLintConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Naming = []*NamingRule{{ID: "orphan-table", Target: "table", Pattern: "^a"}}
+	if _, err := cfg.LintRules(); err == nil || err.Error() != "duplicated lint rule: orphan-table" {
+		t.Errorf("want duplicated lint rule got %v", err)
+	}
+
+	cfg.Naming = nil
+	cfg.Suppressions = []*Suppression{{Table: "customer", Rules: []string{"orphan-tabel"}}}
+	if _, err := cfg.LintRules(); err == nil || err.Error() != "unknown lint rule in suppression of customer: orphan-tabel" {
+		t.Errorf("want unknown lint rule got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+// directions to walk foreign keys
+const (
+	// DirectionReferenced walk to tables referenced by foreign keys of a table
+	DirectionReferenced = "referenced"
+	// DirectionReferencing walk to tables with foreign keys referencing a table
+	DirectionReferencing = "referencing"
+	// DirectionBoth walk foreign keys in both directions
+	DirectionBoth = "both"
+)
+
+// ExpandTables expand seeds to tables within depth hops in the foreign key
+// graph of tbls. Tables reached at the last hop are marked as boundary, and
+// foreign keys to tables outside of the result are dropped.
+func ExpandTables(tbls []*Table, seeds []*Table, depth int, direction string) []*Table {
+	referencing := make(map[*Table][]*Table)
+	for _, tbl := range tbls {
+		for _, fk := range tbl.ForeingKeys {
+			if fk.TargetTable != nil && fk.TargetTable != tbl {
+				referencing[fk.TargetTable] = append(referencing[fk.TargetTable], tbl)
+			}
+		}
+	}
+	hops := make(map[*Table]int)
+	for _, s := range seeds {
+		hops[s] = 0
+	}
+	frontier := seeds
+	for d := 1; d <= depth && len(frontier) != 0; d++ {
+		var next []*Table
+		for _, tbl := range frontier {
+			var neighbors []*Table
+			if direction != DirectionReferencing {
+				for _, fk := range tbl.ForeingKeys {
+					if fk.TargetTable != nil {
+						neighbors = append(neighbors, fk.TargetTable)
+					}
+				}
+			}
+			if direction != DirectionReferenced {
+				neighbors = append(neighbors, referencing[tbl]...)
+			}
+			for _, n := range neighbors {
+				if _, found := hops[n]; !found {
+					hops[n] = d
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	var target []*Table
+	for _, tbl := range tbls {
+		d, found := hops[tbl]
+		if !found {
+			continue
+		}
+		tbl.Boundary = depth > 0 && d == depth
+		var fks []*ForeignKey
+		for _, fk := range tbl.ForeingKeys {
+			if _, found := hops[fk.TargetTable]; found {
+				fks = append(fks, fk)
+			}
+		}
+		tbl.ForeingKeys = fks
+		target = append(target, tbl)
+	}
+	return target
+}
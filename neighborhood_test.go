@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func tableNames(tbls []*Table) []string {
+	var names []string
+	for _, tbl := range tbls {
+		names = append(names, tbl.Name)
+	}
+	return names
+}
+
+func TestExpandTables(t *testing.T) {
+	cases := []struct {
+		seed      string
+		depth     int
+		direction string
+		expected  []string
+		boundary  []string
+	}{
+		{seed: "order_detail", depth: 1, direction: DirectionBoth,
+			expected: []string{"customer_order", "order_detail", "order_detail_approval"},
+			boundary: []string{"customer_order", "order_detail_approval"}},
+		{seed: "order_detail", depth: 2, direction: DirectionReferenced,
+			expected: []string{"customer", "customer_order", "order_detail"},
+			boundary: []string{"customer"}},
+		{seed: "customer_order", depth: 1, direction: DirectionReferencing,
+			expected: []string{"customer_order", "order_detail"},
+			boundary: []string{"order_detail"}},
+		{seed: "customer", depth: 5, direction: DirectionReferenced,
+			expected: []string{"customer"}},
+	}
+	for _, c := range cases {
+		all := testTables()
+		tbls := ExpandTables(all, MatchTables(all, []string{"^" + c.seed + "$"}), c.depth, c.direction)
+		if got := strings.Join(tableNames(tbls), ","); got != strings.Join(c.expected, ",") {
+			t.Errorf("%s %d %s: want %v got %s", c.seed, c.depth, c.direction, c.expected, got)
+		}
+		var boundary []string
+		for _, tbl := range tbls {
+			if tbl.Boundary {
+				boundary = append(boundary, tbl.Name)
+			}
+			for _, fk := range tbl.ForeingKeys {
+				if !strings.Contains(","+strings.Join(c.expected, ",")+",", ","+fk.TargetTableName+",") {
+					t.Errorf("%s: fk to %s is not dropped", c.seed, fk.TargetTableName)
+				}
+			}
+		}
+		if got := strings.Join(boundary, ","); got != strings.Join(c.boundary, ",") {
+			t.Errorf("%s %d %s: want boundary %v got %s", c.seed, c.depth, c.direction, c.boundary, got)
+		}
+	}
+}
+
+func TestBoundaryToPlantUML(t *testing.T) {
+	all := testTables()
+	tbls := ExpandTables(all, MatchTables(all, []string{"^order_detail$"}), 1, DirectionReferenced)
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**customer_order**" #EEEEEE {
+}
+
+entity "**order_detail**" {
+  + ""id"": //bigserial [PK]//
+  + ""customer_order_id"": //bigint [PK][FK]//
+  --
+  *""amount"": //bigint //
+}
+
+"**order_detail**" }o--|| "**customer_order**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestBoundaryToDot(t *testing.T) {
+	all := testTables()
+	tbls := ExpandTables(all, MatchTables(all, []string{"^order_detail$"}), 1, DirectionReferenced)
+	buf, err := TableToDotNode(tbls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+  "customer_order" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="#EEEEEE"><font color="grey">customer_order</font></td></tr>
+    </table>>];
+
+  "order_detail" [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>order_detail</b></td></tr>
+      <tr><td port="id" align="left"><u>id</u>* : bigserial [PK]</td></tr>
+      <tr><td port="customer_order_id" align="left"><u>customer_order_id</u>* : bigint [PK] [FK]</td></tr>
+      <tr><td port="amount" align="left">amount* : bigint</td></tr>
+    </table>>];
+`
+	if string(buf) != expected {
+		t.Errorf("want %s got %s", expected, buf)
+	}
+	buf, err = ForeignKeyToDotEdge(tbls, LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `
+  "order_detail":"customer_order_id" -> "customer_order" [arrowtail=crowodot, arrowhead=teetee];
+`
+	if string(buf) != expected {
+		t.Errorf("want %s got %s", expected, buf)
+	}
+}
+
+func TestBoundaryToMermaid(t *testing.T) {
+	all := testTables()
+	tbls := ExpandTables(all, MatchTables(all, []string{"^order_detail$"}), 1, DirectionReferenced)
+	buf, err := TableToMermaidEntry(tbls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+  customer_order {
+  }
+
+  order_detail {
+    bigserial id PK
+    bigint customer_order_id PK, FK
+    bigint amount
+  }
+`
+	if string(buf) != expected {
+		t.Errorf("want %s got %s", expected, buf)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// LoadPartitionDef load partitions of partitioned tables
+func LoadPartitionDef(db Queryer, schemas []string, tbls []*Table, cols map[string]map[string][]*Column) ([]*Table, error) {
+	return LoadPartitionDefContext(context.Background(), withContext(db), schemas, tbls, cols)
+}
+
+// LoadPartitionDefContext load partitions of partitioned tables with context.
+// Columns are taken from cols loaded by LoadSchemasColumnDefContext with
+// partitions. Partition keys are set to partitioned tables in tbls and the loaded partitions.
+func LoadPartitionDefContext(ctx context.Context, db QueryerContext, schemas []string, tbls []*Table, cols map[string]map[string][]*Column) ([]*Table, error) {
+	var parts []*Table
+	for _, schema := range schemas {
+		ps, err := loadPartitionsContext(ctx, db, schema, cols[schema])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, ps...)
+	}
+	all := append(append([]*Table{}, tbls...), parts...)
+	for _, p := range parts {
+		parent, found := FindTableBySchemaName(all, p.PartitionParentSchema, p.PartitionParentName)
+		if !found {
+			return nil, errors.Errorf("%s.%s not found", p.PartitionParentSchema, p.PartitionParentName)
+		}
+		p.PartitionParent = parent
+	}
+	for _, schema := range schemas {
+		if err := loadPartitionKeyContext(ctx, db, schema, all); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+func loadPartitionsContext(ctx context.Context, db QueryerContext, schema string, cols map[string][]*Column) ([]*Table, error) {
+	pDefs, err := db.QueryContext(ctx, partitionDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load partition def")
+	}
+	defer pDefs.Close()
+	var parts []*Table
+	for pDefs.Next() {
+		p := &Table{Schema: schema, Kind: TableKindTable}
+		err := pDefs.Scan(
+			&p.Name,
+			&p.Comment,
+			&p.PartitionParentSchema,
+			&p.PartitionParentName,
+			&p.PartitionBound,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		parts = append(parts, p)
+	}
+	if err := pDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load partition def")
+	}
+	for _, p := range parts {
+		p.Columns = cols[p.Name]
+		setAutoGenPk(p)
+	}
+	return parts, nil
+}
+
+func loadPartitionKeyContext(ctx context.Context, db QueryerContext, schema string, tbls []*Table) error {
+	keys, err := db.QueryContext(ctx, partitionKeySQL, schema)
+	if err != nil {
+		return errors.Wrap(err, "failed to load partition key")
+	}
+	defer keys.Close()
+	for keys.Next() {
+		var name, key string
+		if err := keys.Scan(&name, &key); err != nil {
+			return errors.Wrap(err, "failed to scan")
+		}
+		if tbl, found := FindTableBySchemaName(tbls, schema, name); found {
+			tbl.PartitionKey = key
+		}
+	}
+	return keys.Err()
+}
+
+// PartitionToUMLRelation partition to partitioned table relation
+func PartitionToUMLRelation(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("partitionRelation").Funcs(umlFuncMap(isMultiSchema(tbls))).Parse(partitionRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[*Table]bool)
+	for _, tbl := range tbls {
+		rendered[tbl] = true
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		if !tbl.IsPartition() || !rendered[tbl.PartitionParent] {
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, tbl); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
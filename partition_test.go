@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPartitionToPlantUML(t *testing.T) {
+	tbls := FilterTables(true, testTables(withPartitions), []string{"^events"})
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**events**" {
+  //PARTITION BY RANGE (created_at)//
+  ..
+  --
+  *""id"": //bigint //
+  *""created_at"": //timestamp with time zone //
+}
+
+entity "**events_2024_01**" <<partition>> {
+  //FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')//
+}
+
+entity "**events_2024_02**" <<partition>> {
+  //FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')//
+}
+
+"**events_2024_01**" --|> "**events**"
+
+"**events_2024_02**" --|> "**events**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+
+	// parent is filtered out
+	buf, err = PartitionToUMLRelation(tbls[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 0 {
+		t.Errorf("unexpected relation to filtered table\n%s", buf)
+	}
+}
+
+func TestPartitionSnapshot(t *testing.T) {
+	buf, err := TableToYAML(testTables(withPartitions), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, part := tbls[4], tbls[5]
+	if events.PartitionKey != "RANGE (created_at)" {
+		t.Errorf("want %s got %s", "RANGE (created_at)", events.PartitionKey)
+	}
+	if !part.IsPartition() || part.PartitionParent != events {
+		t.Errorf("partition parent is not resolved: %+v", part)
+	}
+}
+
+func TestLoadPartitionDef(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	schemas := []string{"public"}
+	cols, err := LoadSchemasColumnDefContext(ctx, conn, schemas, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := cols["public"]["delivery_event_2024_01"]; found {
+		t.Error("columns of partition are loaded without partitions")
+	}
+	cols, err = LoadSchemasColumnDefContext(ctx, conn, schemas, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := LoadSchemasTableDefWithColumnsContext(ctx, conn, schemas, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, found := FindTableByName(tbls, "delivery_event")
+	if !found {
+		t.Fatal("delivery_event not found")
+	}
+	if _, found := FindTableByName(tbls, "delivery_event_2024_01"); found {
+		t.Error("partition is loaded as table")
+	}
+	parts, err := LoadPartitionDefContext(ctx, conn, schemas, tbls, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.PartitionKey != "RANGE (occurred_on)" {
+		t.Errorf("want RANGE (occurred_on) got %q", parent.PartitionKey)
+	}
+	var got []string
+	for _, p := range parts {
+		if p.PartitionParent != parent {
+			t.Errorf("%s: parent is not resolved", p.Name)
+		}
+		got = append(got, fmt.Sprintf("%s %s.%s %d %s", p.Name, p.PartitionParentSchema, p.PartitionParentName,
+			len(p.Columns), p.PartitionBound))
+	}
+	expected := []string{
+		"delivery_event_2024_01 public.delivery_event 3 FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')",
+		"delivery_event_2024_02 public.delivery_event 3 FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
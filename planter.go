@@ -2,14 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
-	"html/template"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"text/template"
+	"time"
 
-	_ "github.com/lib/pq" // postgres
+	"github.com/lib/pq" // postgres
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +23,37 @@ type Queryer interface {
 	QueryRow(string, ...interface{}) *sql.Row
 }
 
+// QueryerContext database/sql compatible query interface with context
+type QueryerContext interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// noContextQueryer QueryerContext ignoring context for Queryer without context support
+type noContextQueryer struct {
+	Queryer
+}
+
+func (q noContextQueryer) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.Exec(query, args...)
+}
+
+func (q noContextQueryer) QueryContext(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.Query(query, args...)
+}
+
+func (q noContextQueryer) QueryRowContext(_ context.Context, query string, args ...interface{}) *sql.Row {
+	return q.QueryRow(query, args...)
+}
+
+func withContext(db Queryer) QueryerContext {
+	if qc, ok := db.(QueryerContext); ok {
+		return qc
+	}
+	return noContextQueryer{db}
+}
+
 // OpenDB opens database connection
 func OpenDB(connStr string) (*sql.DB, error) {
 	conn, err := sql.Open("postgres", connStr)
@@ -29,6 +63,14 @@ func OpenDB(connStr string) (*sql.DB, error) {
 	return conn, nil
 }
 
+// SetStatementTimeout set statement_timeout of the session
+func SetStatementTimeout(ctx context.Context, db QueryerContext, d time.Duration) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", d.Milliseconds())); err != nil {
+		return errors.Wrap(err, "failed to set statement_timeout")
+	}
+	return nil
+}
+
 // Column postgres columns
 type Column struct {
 	FieldOrdinal int
@@ -39,9 +81,62 @@ type Column struct {
 	NotNull      bool
 	IsPrimaryKey bool
 	IsForeignKey bool
+	IsUniqueKey  bool
+	// TypeSchema and TypeName schema and name of column type, or of its
+	// element type for arrays
+	TypeSchema string
+	TypeName   string
+	TypeDef    *TypeDef
+	// Default default expression, empty if the column has no default
+	Default string
+	// VolatileDefault default expression calls a volatile function like nextval
+	VolatileDefault bool
+	// Identity ALWAYS or BY DEFAULT for identity columns, empty otherwise
+	Identity string
+	// Generated expression of generated column, empty otherwise
+	Generated string
+	// Change change status set by HighlightChanges
+	Change Change
 }
 
-// ForeignKey foreign key
+// IsSerial column is serial, bigserial or smallserial
+func (c *Column) IsSerial() bool {
+	return strings.HasSuffix(c.DDLType, "serial")
+}
+
+// IsAutoGenerated value of column is generated by database when omitted.
+// Constant defaults are not generated values.
+func (c *Column) IsAutoGenerated() bool {
+	return c.Identity != "" || c.IsSerial() || c.VolatileDefault
+}
+
+// DefaultClause default, identity or generation clause of column.
+// Defaults of serial columns are omitted since they are implied by the type.
+func (c *Column) DefaultClause() string {
+	switch {
+	case c.Identity != "":
+		return "GENERATED " + c.Identity + " AS IDENTITY"
+	case c.Generated != "":
+		return "GENERATED ALWAYS AS (" + c.Generated + ") STORED"
+	case c.Default != "" && !c.IsSerial():
+		return "DEFAULT " + c.Default
+	default:
+		return ""
+	}
+}
+
+// ForeignKeyColumn column pair of foreign key
+type ForeignKeyColumn struct {
+	SourceColName         string
+	IsSourceColPrimaryKey bool
+	SourceColumn          *Column
+	TargetColName         string
+	IsTargetColPrimaryKey bool
+	TargetColumn          *Column
+}
+
+// ForeignKey foreign key constraint. Columns holds column pairs in the order
+// of constraint definition, and Source/Target column fields are the first pair.
 type ForeignKey struct {
 	ConstraintName        string
 	SourceTableName       string
@@ -49,49 +144,246 @@ type ForeignKey struct {
 	IsSourceColPrimaryKey bool
 	SourceTable           *Table
 	SourceColumn          *Column
+	TargetSchema          string
 	TargetTableName       string
 	TargetColName         string
 	IsTargetColPrimaryKey bool
 	TargetTable           *Table
 	TargetColumn          *Column
+	Columns               []*ForeignKeyColumn
+	// OnDelete referential action like CASCADE, empty means NO ACTION
+	OnDelete string
+	// OnUpdate referential action like CASCADE, empty means NO ACTION
+	OnUpdate            string
+	IsDeferrable        bool
+	IsInitiallyDeferred bool
+	// NotValid constraint is added with NOT VALID and not validated yet
+	NotValid bool
+	// Change change status set by HighlightChanges
+	Change Change
 }
 
-// IsOneToOne returns true if one to one relation
-// - in case of composite pk
-//     * one to one
-//         * source table is composite pk && target table is composite pk
-//             * source table fks to target table are all pks
-//     * other cases are one to many
-func (k *ForeignKey) IsOneToOne() bool {
-	switch {
-	case k.SourceTable.IsCompositePK() && k.TargetTable.IsCompositePK():
-		var targetFks []*ForeignKey
-		for _, fk := range k.SourceTable.ForeingKeys {
-			if fk.TargetTableName == k.TargetTableName {
-				targetFks = append(targetFks, fk)
-			}
+// relationship label modes
+const (
+	LabelNone       = "none"
+	LabelColumn     = "column"
+	LabelConstraint = "constraint"
+)
+
+// relationLabel label of relationship in mode, so that parallel and self
+// referencing relationships are distinguishable. mode is one of LabelNone,
+// LabelColumn or LabelConstraint.
+func relationLabel(mode string) func(*ForeignKey) string {
+	return func(k *ForeignKey) string {
+		switch mode {
+		case LabelColumn:
+			return strings.Join(k.SourceColNames(), ", ")
+		case LabelConstraint:
+			return k.ConstraintName
+		default:
+			return ""
 		}
-		for _, tfk := range targetFks {
-			if !tfk.IsSourceColPrimaryKey || !tfk.IsTargetColPrimaryKey {
-				return false
-			}
+	}
+}
+
+// umlRelationLabel label and referential actions of relationship. Line
+// breaks are replaced since they end the relation line.
+func umlRelationLabel(mode string) func(*ForeignKey) string {
+	label := relationLabel(mode)
+	return func(k *ForeignKey) string {
+		s := strings.TrimSpace(label(k) + " " + k.ActionLabel())
+		return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+	}
+}
+
+// ForeignKey referential actions
+const (
+	ForeignKeyActionNoAction   = "NO ACTION"
+	ForeignKeyActionRestrict   = "RESTRICT"
+	ForeignKeyActionCascade    = "CASCADE"
+	ForeignKeyActionSetNull    = "SET NULL"
+	ForeignKeyActionSetDefault = "SET DEFAULT"
+)
+
+// IsCascadeDelete rows are deleted when referenced rows are deleted
+func (k *ForeignKey) IsCascadeDelete() bool {
+	return k.OnDelete == ForeignKeyActionCascade
+}
+
+// ActionLabel referential actions, deferrability and validity of fk.
+// Defaults (NO ACTION, NOT DEFERRABLE and validated) are omitted.
+func (k *ForeignKey) ActionLabel() string {
+	var s []string
+	if k.OnDelete != "" && k.OnDelete != ForeignKeyActionNoAction {
+		s = append(s, "ON DELETE "+k.OnDelete)
+	}
+	if k.OnUpdate != "" && k.OnUpdate != ForeignKeyActionNoAction {
+		s = append(s, "ON UPDATE "+k.OnUpdate)
+	}
+	if k.IsDeferrable {
+		if k.IsInitiallyDeferred {
+			s = append(s, "DEFERRABLE INITIALLY DEFERRED")
+		} else {
+			s = append(s, "DEFERRABLE")
 		}
+	}
+	if k.NotValid {
+		s = append(s, "NOT VALID")
+	}
+	return strings.Join(s, " ")
+}
+
+// SourceColNames source column names
+func (k *ForeignKey) SourceColNames() []string {
+	var names []string
+	for _, c := range k.Columns {
+		names = append(names, c.SourceColName)
+	}
+	return names
+}
+
+// TargetColNames target column names
+func (k *ForeignKey) TargetColNames() []string {
+	var names []string
+	for _, c := range k.Columns {
+		names = append(names, c.TargetColName)
+	}
+	return names
+}
+
+// Cardinality number of rows on one side of a relation
+type Cardinality int
+
+// cardinalities of crow's foot notation
+const (
+	CardinalityZeroOrOne Cardinality = iota + 1
+	CardinalityExactlyOne
+	CardinalityZeroOrMore
+	CardinalityOneOrMore
+)
+
+// crow's foot symbols at the left (source) and right (target) end of a
+// relation, shared by PlantUML and Mermaid
+var (
+	leftCrowsFoot = map[Cardinality]string{
+		CardinalityZeroOrOne:  "|o",
+		CardinalityExactlyOne: "||",
+		CardinalityZeroOrMore: "}o",
+		CardinalityOneOrMore:  "}|",
+	}
+	rightCrowsFoot = map[Cardinality]string{
+		CardinalityZeroOrOne:  "o|",
+		CardinalityExactlyOne: "||",
+		CardinalityZeroOrMore: "o{",
+		CardinalityOneOrMore:  "|{",
+	}
+)
+
+// Cardinality cardinality of source and target side of relation.
+//   - a target row is referenced by zero or one source rows if source
+//     columns are unique in source table, zero or more rows otherwise
+//   - a source row references exactly one target row if all source
+//     columns are NOT NULL, zero or one row otherwise
+func (k *ForeignKey) Cardinality() (source, target Cardinality) {
+	source = CardinalityZeroOrMore
+	if k.IsSourceUnique() {
+		source = CardinalityZeroOrOne
+	}
+	target = CardinalityExactlyOne
+	for _, c := range k.Columns {
+		if c.SourceColumn == nil || !c.SourceColumn.NotNull {
+			target = CardinalityZeroOrOne
+			break
+		}
+	}
+	return source, target
+}
+
+// IsSourceUnique returns true if source columns are exactly the primary key
+// or a unique constraint of source table. Unique indexes are not considered
+// so that cardinality does not depend on whether indexes are loaded.
+func (k *ForeignKey) IsSourceUnique() bool {
+	names := k.SourceColNames()
+	var pk []string
+	for _, c := range k.SourceTable.Columns {
+		if c.IsPrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	if len(pk) != 0 && sameColumns(pk, names) {
 		return true
-	case !k.SourceTable.IsCompositePK() && k.SourceColumn.IsPrimaryKey && k.TargetColumn.IsPrimaryKey:
-		return true
-	default:
+	}
+	for _, con := range k.SourceTable.Constraints {
+		if con.Type == ConstraintTypeUnique && sameColumns(con.ColumnNames, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColumns returns true if a and b have the same columns in any order
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
 		return false
 	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
-// Table postgres table
+// TableKind kind of relation
+type TableKind string
+
+// relation kinds
+const (
+	TableKindTable            TableKind = "table"
+	TableKindView             TableKind = "view"
+	TableKindMaterializedView TableKind = "materialized view"
+)
+
+// Table postgres table, view or materialized view
 type Table struct {
-	Schema      string
-	Name        string
-	Comment     sql.NullString
-	AutoGenPk   bool
-	Columns     []*Column
-	ForeingKeys []*ForeignKey
+	Schema       string
+	Name         string
+	Kind         TableKind
+	Comment      sql.NullString
+	AutoGenPk    bool
+	Columns      []*Column
+	Constraints  []*Constraint
+	Indexes      []*Index
+	ForeingKeys  []*ForeignKey
+	Dependencies []*ViewDependency
+
+	PartitionKey          string
+	PartitionBound        string
+	PartitionParentSchema string
+	PartitionParentName   string
+	PartitionParent       *Table
+
+	// IndexesLoaded Indexes are loaded, otherwise Indexes is empty even if
+	// the table has indexes
+	IndexesLoaded bool
+	// Boundary table is rendered collapsed since its neighbors are omitted
+	Boundary bool
+	// Change change status set by HighlightChanges
+	Change Change
+}
+
+// IsPartition returns true if partition of partitioned table
+func (t *Table) IsPartition() bool {
+	return t.PartitionParentName != ""
+}
+
+// IsView returns true if view or materialized view
+func (t *Table) IsView() bool {
+	return t.Kind == TableKindView || t.Kind == TableKindMaterializedView
 }
 
 // IsCompositePK check if table is composite pk
@@ -108,6 +400,16 @@ func (t *Table) IsCompositePK() bool {
 	return false
 }
 
+// setAutoGenPk set AutoGenPk if a primary key column is generated by database
+func setAutoGenPk(t *Table) {
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey && c.IsAutoGenerated() {
+			t.AutoGenPk = true
+			return
+		}
+	}
+}
+
 func stripCommentSuffix(s string) string {
 	if tok := strings.SplitN(s, "\t", 2); len(tok) == 2 {
 		return tok[0]
@@ -125,6 +427,26 @@ func FindTableByName(tbls []*Table, name string) (*Table, bool) {
 	return nil, false
 }
 
+// FindTableBySchemaName find table by schema qualified name
+func FindTableBySchemaName(tbls []*Table, schema, name string) (*Table, bool) {
+	for _, tbl := range tbls {
+		if tbl.Schema == schema && tbl.Name == name {
+			return tbl, true
+		}
+	}
+	return nil, false
+}
+
+// FindColumn find column of table by name
+func (t *Table) FindColumn(name string) (*Column, bool) {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
 // FindColumnByName find table by name
 func FindColumnByName(tbls []*Table, tableName, colName string) (*Column, bool) {
 	for _, tbl := range tbls {
@@ -139,24 +461,40 @@ func FindColumnByName(tbls []*Table, tableName, colName string) (*Column, bool)
 	return nil, false
 }
 
+func columnScanDest(c *Column) []interface{} {
+	return []interface{}{
+		&c.FieldOrdinal,
+		&c.Name,
+		&c.Comment,
+		&c.DataType,
+		&c.NotNull,
+		&c.IsPrimaryKey,
+		&c.DDLType,
+		&c.Default,
+		&c.Identity,
+		&c.Generated,
+		&c.VolatileDefault,
+		&c.TypeSchema,
+		&c.TypeName,
+	}
+}
+
 // LoadColumnDef load Postgres column definition
 func LoadColumnDef(db Queryer, schema, table string) ([]*Column, error) {
-	colDefs, err := db.Query(columDefSQL, schema, table)
+	return LoadColumnDefContext(context.Background(), withContext(db), schema, table)
+}
+
+// LoadColumnDefContext load Postgres column definition with context
+func LoadColumnDefContext(ctx context.Context, db QueryerContext, schema, table string) ([]*Column, error) {
+	colDefs, err := db.QueryContext(ctx, columDefSQL, schema, table)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load table def")
 	}
+	defer colDefs.Close()
 	var cols []*Column
 	for colDefs.Next() {
 		var c Column
-		err := colDefs.Scan(
-			&c.FieldOrdinal,
-			&c.Name,
-			&c.Comment,
-			&c.DataType,
-			&c.NotNull,
-			&c.IsPrimaryKey,
-			&c.DDLType,
-		)
+		err := colDefs.Scan(columnScanDest(&c)...)
 		c.Comment.String = stripCommentSuffix(c.Comment.String)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan")
@@ -166,61 +504,281 @@ func LoadColumnDef(db Queryer, schema, table string) ([]*Column, error) {
 	return cols, nil
 }
 
+// LoadSchemaColumnDef load Postgres column definitions of all tables in schema.
+// Columns of views and partitions are loaded only if requested.
+func LoadSchemaColumnDef(db Queryer, schema string, views, partitions bool) (map[string][]*Column, error) {
+	return LoadSchemaColumnDefContext(context.Background(), withContext(db), schema, views, partitions)
+}
+
+// LoadSchemaColumnDefContext load Postgres column definitions of all tables in schema with context
+func LoadSchemaColumnDefContext(ctx context.Context, db QueryerContext, schema string, views, partitions bool) (map[string][]*Column, error) {
+	kinds := []string{"r", "p"}
+	if views {
+		kinds = append(kinds, "v", "m")
+	}
+	colDefs, err := db.QueryContext(ctx, schemaColumDefSQL, schema, pq.Array(kinds), partitions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	defer colDefs.Close()
+	cols := make(map[string][]*Column)
+	for colDefs.Next() {
+		var (
+			tblName string
+			c       Column
+		)
+		err := colDefs.Scan(append([]interface{}{&tblName}, columnScanDest(&c)...)...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		c.Comment.String = stripCommentSuffix(c.Comment.String)
+		cols[tblName] = append(cols[tblName], &c)
+	}
+	if err := colDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	return cols, nil
+}
+
+func foreignKeyScanDest(fk *ForeignKey) []interface{} {
+	return []interface{}{
+		&fk.SourceColName,
+		&fk.TargetTableName,
+		&fk.TargetColName,
+		&fk.ConstraintName,
+		&fk.IsTargetColPrimaryKey,
+		&fk.IsSourceColPrimaryKey,
+		&fk.TargetSchema,
+		&fk.OnDelete,
+		&fk.OnUpdate,
+		&fk.IsDeferrable,
+		&fk.IsInitiallyDeferred,
+		&fk.NotValid,
+	}
+}
+
+// appendForeignKeyRow append a row of fk definition. Composite fk is loaded
+// as one row per column pair, and rows of the same constraint are grouped.
+func appendForeignKeyRow(fks []*ForeignKey, row *ForeignKey) []*ForeignKey {
+	c := &ForeignKeyColumn{
+		SourceColName:         row.SourceColName,
+		IsSourceColPrimaryKey: row.IsSourceColPrimaryKey,
+		TargetColName:         row.TargetColName,
+		IsTargetColPrimaryKey: row.IsTargetColPrimaryKey,
+	}
+	if n := len(fks); n != 0 {
+		last := fks[n-1]
+		if last.SourceTableName == row.SourceTableName && last.ConstraintName == row.ConstraintName {
+			last.Columns = append(last.Columns, c)
+			return fks
+		}
+	}
+	row.Columns = []*ForeignKeyColumn{c}
+	return append(fks, row)
+}
+
+// isLoadedSchema returns true if fk of schema referencing targetSchema can be
+// resolved. fks referencing tables in schemas which are not loaded are
+// skipped instead of failing to resolve.
+func isLoadedSchema(tbls []*Table, schema, targetSchema string) bool {
+	if targetSchema == "" || targetSchema == schema {
+		return true
+	}
+	for _, tbl := range tbls {
+		if tbl.Schema == targetSchema {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveForeignKeys set table and column references of fks. Target tables
+// are looked up by schema qualified name, so tbls can contain multiple schemas.
+func resolveForeignKeys(tbls []*Table, fks []*ForeignKey) error {
+	for _, fk := range fks {
+		if fk.TargetSchema == "" {
+			fk.TargetSchema = fk.SourceTable.Schema
+		}
+		targetTbl, found := FindTableBySchemaName(tbls, fk.TargetSchema, fk.TargetTableName)
+		if !found {
+			if fk.TargetSchema != fk.SourceTable.Schema {
+				return errors.Errorf("%s.%s not found", fk.TargetSchema, fk.TargetTableName)
+			}
+			return errors.Errorf("%s not found", fk.TargetTableName)
+		}
+		fk.TargetTable = targetTbl
+		for _, c := range fk.Columns {
+			targetCol, found := targetTbl.FindColumn(c.TargetColName)
+			if !found {
+				return errors.Errorf("%s.%s not found", fk.TargetTableName, c.TargetColName)
+			}
+			c.TargetColumn = targetCol
+			sourceCol, found := fk.SourceTable.FindColumn(c.SourceColName)
+			if !found {
+				return errors.Errorf("%s.%s not found", fk.SourceTableName, c.SourceColName)
+			}
+			sourceCol.IsForeignKey = true
+			c.SourceColumn = sourceCol
+		}
+		if len(fk.Columns) != 0 {
+			fk.SourceColumn = fk.Columns[0].SourceColumn
+			fk.TargetColumn = fk.Columns[0].TargetColumn
+		}
+	}
+	return nil
+}
+
 // LoadForeignKeyDef load Postgres fk definition
 func LoadForeignKeyDef(db Queryer, schema string, tbls []*Table, tbl *Table) ([]*ForeignKey, error) {
-	fkDefs, err := db.Query(fkDefSQL, schema, tbl.Name)
+	return LoadForeignKeyDefContext(context.Background(), withContext(db), schema, tbls, tbl)
+}
+
+// LoadForeignKeyDefContext load Postgres fk definition with context
+func LoadForeignKeyDefContext(ctx context.Context, db QueryerContext, schema string, tbls []*Table, tbl *Table) ([]*ForeignKey, error) {
+	fkDefs, err := db.QueryContext(ctx, fkDefSQL, schema, tbl.Name)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load fk def")
 	}
+	defer fkDefs.Close()
 	var fks []*ForeignKey
 	for fkDefs.Next() {
 		fk := ForeignKey{
 			SourceTableName: tbl.Name,
 			SourceTable:     tbl,
 		}
-		err := fkDefs.Scan(
-			&fk.SourceColName,
-			&fk.TargetTableName,
-			&fk.TargetColName,
-			&fk.ConstraintName,
-			&fk.IsTargetColPrimaryKey,
-			&fk.IsSourceColPrimaryKey,
-		)
+		err := fkDefs.Scan(foreignKeyScanDest(&fk)...)
 		if err != nil {
 			return nil, err
 		}
-		fks = append(fks, &fk)
+		if !isLoadedSchema(tbls, schema, fk.TargetSchema) {
+			continue
+		}
+		fks = appendForeignKeyRow(fks, &fk)
 	}
-	for _, fk := range fks {
-		targetTbl, found := FindTableByName(tbls, fk.TargetTableName)
-		if !found {
-			return nil, errors.Errorf("%s not found", fk.TargetTableName)
+	if err := resolveForeignKeys(tbls, fks); err != nil {
+		return nil, err
+	}
+	return fks, nil
+}
+
+// LoadSchemaForeignKeyDef load Postgres fk definitions of all tables in schema.
+// fks of tables not in tbls are ignored.
+func LoadSchemaForeignKeyDef(db Queryer, schema string, tbls []*Table) (map[string][]*ForeignKey, error) {
+	return LoadSchemaForeignKeyDefContext(context.Background(), withContext(db), schema, tbls)
+}
+
+// LoadSchemaForeignKeyDefContext load Postgres fk definitions of all tables in schema with context
+func LoadSchemaForeignKeyDefContext(ctx context.Context, db QueryerContext, schema string, tbls []*Table) (map[string][]*ForeignKey, error) {
+	fkDefs, err := db.QueryContext(ctx, schemaFkDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load fk def")
+	}
+	defer fkDefs.Close()
+	fks := make(map[string][]*ForeignKey)
+	for fkDefs.Next() {
+		var fk ForeignKey
+		err := fkDefs.Scan(append([]interface{}{&fk.SourceTableName}, foreignKeyScanDest(&fk)...)...)
+		if err != nil {
+			return nil, err
 		}
-		fk.TargetTable = targetTbl
-		targetCol, found := FindColumnByName(tbls, fk.TargetTableName, fk.TargetColName)
-		if !found {
-			return nil, errors.Errorf("%s.%s not found", fk.TargetTableName, fk.TargetColName)
+		tbl, found := FindTableBySchemaName(tbls, schema, fk.SourceTableName)
+		if !found || !isLoadedSchema(tbls, schema, fk.TargetSchema) {
+			continue
 		}
-		fk.TargetColumn = targetCol
-		sourceCol, found := FindColumnByName(tbls, fk.SourceTableName, fk.SourceColName)
-		if !found {
-			return nil, errors.Errorf("%s.%s not found", fk.SourceTableName, fk.SourceColName)
+		fk.SourceTable = tbl
+		fks[tbl.Name] = appendForeignKeyRow(fks[tbl.Name], &fk)
+	}
+	if err := fkDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load fk def")
+	}
+	for _, tbl := range tbls {
+		if tbl.Schema != schema {
+			continue
+		}
+		if err := resolveForeignKeys(tbls, fks[tbl.Name]); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to get fks of %s", tbl.Name))
 		}
-		sourceCol.IsForeignKey = true
-		fk.SourceColumn = sourceCol
 	}
 	return fks, nil
 }
 
-// LoadTableDef load Postgres table definition
+// LoadTableDef load Postgres table definition. Columns and fks of all tables
+// are loaded at once, so the number of queries doesn't depend on the number of tables.
 func LoadTableDef(db Queryer, schema string) ([]*Table, error) {
-	tbDefs, err := db.Query(tableDefSQL, schema)
+	return LoadTableDefContext(context.Background(), withContext(db), schema)
+}
+
+// LoadTableDefContext load Postgres table definition with context
+func LoadTableDefContext(ctx context.Context, db QueryerContext, schema string) ([]*Table, error) {
+	return LoadSchemasTableDefContext(ctx, db, []string{schema})
+}
+
+// LoadSchemasTableDef load Postgres table definition of multiple schemas.
+// fks referencing tables in another schema are resolved as long as the schema is loaded.
+func LoadSchemasTableDef(db Queryer, schemas []string) ([]*Table, error) {
+	return LoadSchemasTableDefContext(context.Background(), withContext(db), schemas)
+}
+
+// LoadSchemasTableDefContext load Postgres table definition of multiple schemas with context
+func LoadSchemasTableDefContext(ctx context.Context, db QueryerContext, schemas []string) ([]*Table, error) {
+	cols, err := LoadSchemasColumnDefContext(ctx, db, schemas, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return LoadSchemasTableDefWithColumnsContext(ctx, db, schemas, cols)
+}
+
+// LoadSchemasColumnDefContext load Postgres column definitions of all tables
+// in schemas with context, keyed by schema and relation name. Columns of views
+// and partitions are loaded only if requested.
+func LoadSchemasColumnDefContext(ctx context.Context, db QueryerContext, schemas []string, views, partitions bool) (map[string]map[string][]*Column, error) {
+	cols := make(map[string]map[string][]*Column)
+	for _, schema := range schemas {
+		cs, err := LoadSchemaColumnDefContext(ctx, db, schema, views, partitions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get columns")
+		}
+		cols[schema] = cs
+	}
+	return cols, nil
+}
+
+// LoadSchemasTableDefWithColumnsContext load Postgres table definition of
+// multiple schemas with columns loaded by LoadSchemasColumnDefContext, which
+// are reused by views and partitions
+func LoadSchemasTableDefWithColumnsContext(ctx context.Context, db QueryerContext, schemas []string, cols map[string]map[string][]*Column) ([]*Table, error) {
+	var tbls []*Table
+	for _, schema := range schemas {
+		ts, err := loadTablesContext(ctx, db, schema, cols[schema])
+		if err != nil {
+			return nil, err
+		}
+		tbls = append(tbls, ts...)
+	}
+	for _, schema := range schemas {
+		fks, err := LoadSchemaForeignKeyDefContext(ctx, db, schema, tbls)
+		if err != nil {
+			return nil, err
+		}
+		for _, tbl := range tbls {
+			if tbl.Schema == schema {
+				tbl.ForeingKeys = fks[tbl.Name]
+			}
+		}
+	}
+	return tbls, nil
+}
+
+// loadTablesContext load tables in schema with their columns in cols
+func loadTablesContext(ctx context.Context, db QueryerContext, schema string, cols map[string][]*Column) ([]*Table, error) {
+	tbDefs, err := db.QueryContext(ctx, tableDefSQL, schema)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load table def")
 	}
+	defer tbDefs.Close()
 	var tbls []*Table
 	for tbDefs.Next() {
-		t := &Table{Schema: schema}
+		t := &Table{Schema: schema, Kind: TableKindTable}
 		err := tbDefs.Scan(
 			&t.Name,
 			&t.Comment,
@@ -228,33 +786,202 @@ func LoadTableDef(db Queryer, schema string) ([]*Table, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan")
 		}
-		cols, err := LoadColumnDef(db, schema, t.Name)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to get columns of %s", t.Name))
-		}
-		t.Columns = cols
 		tbls = append(tbls, t)
 	}
+	if err := tbDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load table def")
+	}
+	cons, err := LoadSchemaConstraintDefContext(ctx, db, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get constraints")
+	}
 	for _, tbl := range tbls {
-		fks, err := LoadForeignKeyDef(db, schema, tbls, tbl)
+		tbl.Columns = cols[tbl.Name]
+		tbl.Constraints = cons[tbl.Name]
+		setUniqueKeys(tbl)
+		setAutoGenPk(tbl)
+	}
+	return tbls, nil
+}
+
+// ListSchemasContext expand schema names. Names containing glob meta
+// characters such as app_* are matched against schemas in database.
+func ListSchemasContext(ctx context.Context, db QueryerContext, patterns []string) ([]string, error) {
+	var (
+		all     []string
+		loaded  bool
+		schemas []string
+	)
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, `*?[\`) {
+			if !seen[p] {
+				seen[p] = true
+				schemas = append(schemas, p)
+			}
+			continue
+		}
+		if !loaded {
+			loaded = true
+			rows, err := db.QueryContext(ctx, schemaListSQL)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to load schemas")
+			}
+			for rows.Next() {
+				var n string
+				if err := rows.Scan(&n); err != nil {
+					rows.Close()
+					return nil, errors.Wrap(err, "failed to scan")
+				}
+				all = append(all, n)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return nil, errors.Wrap(err, "failed to load schemas")
+			}
+		}
+		matched, err := matchSchemas(all, p)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to get fks of %s", tbl.Name))
+			return nil, err
+		}
+		if len(matched) == 0 {
+			return nil, errors.Errorf("no schema matches %s", p)
+		}
+		for _, n := range matched {
+			if !seen[n] {
+				seen[n] = true
+				schemas = append(schemas, n)
+			}
 		}
-		tbl.ForeingKeys = fks
 	}
-	return tbls, nil
+	return schemas, nil
+}
+
+func matchSchemas(schemas []string, pattern string) ([]string, error) {
+	var matched []string
+	for _, n := range schemas {
+		ok, err := path.Match(pattern, n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schema pattern: %s", pattern)
+		}
+		if ok {
+			matched = append(matched, n)
+		}
+	}
+	return matched, nil
+}
+
+// TableSchemas schemas of tables in order of appearance
+func TableSchemas(tbls []*Table) []string {
+	var schemas []string
+	seen := make(map[string]bool)
+	for _, tbl := range tbls {
+		if !seen[tbl.Schema] {
+			seen[tbl.Schema] = true
+			schemas = append(schemas, tbl.Schema)
+		}
+	}
+	return schemas
+}
+
+// entityName name of table in diagrams. Names are schema qualified if
+// tables of multiple schemas are rendered, since table names are unique only
+// within a schema.
+func entityName(multiSchema bool) func(schema, name string) string {
+	return func(schema, name string) string {
+		if multiSchema {
+			return schema + "." + name
+		}
+		return name
+	}
+}
+
+// isMultiSchema returns true if tbls belong to more than one schema
+func isMultiSchema(tbls []*Table) bool {
+	return len(TableSchemas(tbls)) > 1
+}
+
+// umlFuncMap template functions for PlantUML
+func umlFuncMap(multiSchema bool) template.FuncMap {
+	return template.FuncMap{
+		"entityName": entityName(multiSchema),
+		"stereotype": func(t *Table) string {
+			switch {
+			case t.IsView():
+				return " <<" + string(t.Kind) + ">>"
+			case t.IsPartition():
+				return " <<partition>>"
+			default:
+				return ""
+			}
+		},
+		"join":          strings.Join,
+		"relationArrow": umlRelationArrow,
+		"tableColor":    umlTableColor,
+		"changeStart":   umlChangeStart,
+		"changeEnd":     umlChangeEnd,
+		"typeStereotype": func(t *TypeDef) string {
+			if t.Kind == TypeKindDomain {
+				return " <<domain>>"
+			}
+			return ""
+		},
+	}
+}
+
+// umlRelationArrow crow's foot arrow of relation. Cascade delete is drawn in
+// red and NOT VALID fk is dashed, unless fk is colored by its change status.
+// One to one relation is drawn horizontally.
+func umlRelationArrow(k *ForeignKey) string {
+	source, target := k.Cardinality()
+	var s []string
+	if c, ok := changeLineColors[k.Change]; ok {
+		s = append(s, c)
+	} else if k.IsCascadeDelete() {
+		s = append(s, "#red")
+	}
+	if k.NotValid {
+		s = append(s, "dashed")
+	}
+	line := "-"
+	if len(s) != 0 {
+		line += "[" + strings.Join(s, ",") + "]"
+	}
+	if source == CardinalityZeroOrMore {
+		line += "-"
+	}
+	return leftCrowsFoot[source] + line + rightCrowsFoot[target]
 }
 
 // TableToUMLEntry table entry
 func TableToUMLEntry(tbls []*Table) ([]byte, error) {
-	tpl, err := template.New("entry").Parse(entryTmpl)
+	return tableToUMLEntry(tbls, isMultiSchema(tbls))
+}
+
+func tableToUMLEntry(tbls []*Table, multiSchema bool) ([]byte, error) {
+	tpl, err := template.New("entry").Funcs(umlFuncMap(multiSchema)).Parse(entryTmpl)
+	if err != nil {
+		return nil, err
+	}
+	ptpl, err := template.New("partition").Funcs(umlFuncMap(multiSchema)).Parse(partitionTmpl)
+	if err != nil {
+		return nil, err
+	}
+	btpl, err := template.New("boundary").Funcs(umlFuncMap(multiSchema)).Parse(boundaryTmpl)
 	if err != nil {
 		return nil, err
 	}
 	var src []byte
 	for _, tbl := range tbls {
 		buf := new(bytes.Buffer)
-		if err := tpl.Execute(buf, tbl); err != nil {
+		t := tpl
+		switch {
+		case tbl.Boundary:
+			t = btpl
+		case tbl.IsPartition():
+			t = ptpl
+		}
+		if err := t.Execute(buf, tbl); err != nil {
 			return nil, errors.Wrapf(err, "failed to execute template: %s", tbl.Name)
 		}
 		src = append(src, buf.Bytes()...)
@@ -262,9 +989,11 @@ func TableToUMLEntry(tbls []*Table) ([]byte, error) {
 	return src, nil
 }
 
-// ForeignKeyToUMLRelation relation
-func ForeignKeyToUMLRelation(tbls []*Table) ([]byte, error) {
-	tpl, err := template.New("relation").Parse(relationTmpl)
+// ForeignKeyToUMLRelation relation labeled in label mode
+func ForeignKeyToUMLRelation(tbls []*Table, label string) ([]byte, error) {
+	multiSchema := isMultiSchema(tbls)
+	tpl, err := template.New("relation").Funcs(umlFuncMap(multiSchema)).
+		Funcs(template.FuncMap{"relationLabel": umlRelationLabel(label)}).Parse(relationTmpl)
 	if err != nil {
 		return nil, err
 	}
@@ -281,6 +1010,74 @@ func ForeignKeyToUMLRelation(tbls []*Table) ([]byte, error) {
 	return src, nil
 }
 
+// TableToPlantUML PlantUML ER diagram. Tables are grouped in packages by
+// schema if tables of multiple schemas are rendered, and relationships are
+// labeled in label mode.
+func TableToPlantUML(tbls []*Table, title, label string) ([]byte, error) {
+	schemas := TableSchemas(tbls)
+	var entry []byte
+	if len(schemas) > 1 {
+		for _, schema := range schemas {
+			var ts []*Table
+			for _, tbl := range tbls {
+				if tbl.Schema == schema {
+					ts = append(ts, tbl)
+				}
+			}
+			e, err := tableToUMLEntry(ts, true)
+			if err != nil {
+				return nil, err
+			}
+			entry = append(entry, []byte("\npackage \""+schema+"\" {\n")...)
+			entry = append(entry, e...)
+			entry = append(entry, []byte("}\n")...)
+		}
+	} else {
+		e, err := TableToUMLEntry(tbls)
+		if err != nil {
+			return nil, err
+		}
+		entry = e
+	}
+	rel, err := ForeignKeyToUMLRelation(tbls, label)
+	if err != nil {
+		return nil, err
+	}
+	dep, err := ViewDependencyToUMLRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	part, err := PartitionToUMLRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	typeEntry, err := TypeDefToUMLEntry(tbls)
+	if err != nil {
+		return nil, err
+	}
+	typeRel, err := TypeDefToUMLRelation(tbls)
+	if err != nil {
+		return nil, err
+	}
+	src := []byte("@startuml\n")
+	if len(title) != 0 {
+		src = append(src, []byte("title "+title+"\n")...)
+	}
+	src = append(src, []byte("hide circle\n"+
+		"skinparam linetype ortho\n")...)
+	if len(schemas) > 1 {
+		src = append(src, []byte("set namespaceSeparator none\n")...)
+	}
+	src = append(src, entry...)
+	src = append(src, typeEntry...)
+	src = append(src, rel...)
+	src = append(src, dep...)
+	src = append(src, part...)
+	src = append(src, typeRel...)
+	src = append(src, []byte("@enduml\n")...)
+	return src, nil
+}
+
 func contains(v string, r []*regexp.Regexp) bool {
 	for _, e := range r {
 		if e != nil && e.MatchString(v) {
@@ -290,23 +1087,61 @@ func contains(v string, r []*regexp.Regexp) bool {
 	return false
 }
 
-// FilterTables filter tables
-func FilterTables(match bool, tbls []*Table, tblNames []string) []*Table {
-	sort.Strings(tblNames)
+// matchTable returns true if name matches r, or schema qualified name
+// matches r across the dot, e.g. sales.customer matches only customer of
+// sales schema
+func matchTable(schema, name string, r []*regexp.Regexp) bool {
+	if contains(name, r) {
+		return true
+	}
+	qualified := schema + "." + name
+	for _, e := range r {
+		if e == nil {
+			continue
+		}
+		for _, loc := range e.FindAllStringIndex(qualified, -1) {
+			if loc[0] <= len(schema) && loc[1] > len(schema) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
+func tableNameExps(tblNames []string) []*regexp.Regexp {
 	var tblExps []*regexp.Regexp
 	for _, tn := range tblNames {
 		str := fmt.Sprintf(`([\\/])?%s([\\/])?`, tn)
 		r := regexp.MustCompile(str)
 		tblExps = append(tblExps, r)
 	}
+	return tblExps
+}
+
+// MatchTables tables matching tblNames. Unlike FilterTables foreign keys are
+// left untouched.
+func MatchTables(tbls []*Table, tblNames []string) []*Table {
+	tblExps := tableNameExps(tblNames)
+	var target []*Table
+	for _, tbl := range tbls {
+		if matchTable(tbl.Schema, tbl.Name, tblExps) {
+			target = append(target, tbl)
+		}
+	}
+	return target
+}
+
+// FilterTables filter tables
+func FilterTables(match bool, tbls []*Table, tblNames []string) []*Table {
+	sort.Strings(tblNames)
+	tblExps := tableNameExps(tblNames)
 
 	var target []*Table
 	for _, tbl := range tbls {
-		if contains(tbl.Name, tblExps) == match {
+		if matchTable(tbl.Schema, tbl.Name, tblExps) == match {
 			var fks []*ForeignKey
 			for _, fk := range tbl.ForeingKeys {
-				if contains(fk.TargetTableName, tblExps) == match {
+				if matchTable(fk.TargetSchema, fk.TargetTableName, tblExps) == match {
 					fks = append(fks, fk)
 				}
 			}
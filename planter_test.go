@@ -1,25 +1,34 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/pkg/errors"
 )
 
-// before running test, create user and database
+// before running test, create user and database, and pgcrypto extension in it
 // CREATE USER planter;
 // CREATE DATABASE planter OWNER planter;
+// CREATE EXTENSION IF NOT EXISTS pgcrypto;
 
-func testPgSetup(t *testing.T) (*sql.DB, func()) {
+// testDSN connection string of test database. Port is taken from DB_PORT.
+func testDSN() string {
 	port := os.Getenv("DB_PORT")
 	if port == "" {
 		port = "5432"
 	}
-	dsn := fmt.Sprintf("user=planter port=%s dbname=planter sslmode=disable", port)
-	conn, err := sql.Open("postgres", dsn)
+	return fmt.Sprintf("user=planter port=%s dbname=planter sslmode=disable", port)
+}
+
+func testPgSetup(t *testing.T) (*sql.DB, func()) {
+	conn, err := sql.Open("postgres", testDSN())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,13 +58,17 @@ func TestLoadColumnDef(t *testing.T) {
 	}
 	expected := []*Column{
 		&Column{
-			FieldOrdinal: 1,
-			Name:         "id",
-			Comment:      sql.NullString{},
-			DataType:     "bigint",
-			DDLType:      "bigserial",
-			NotNull:      true,
-			IsPrimaryKey: true,
+			FieldOrdinal:    1,
+			Name:            "id",
+			Comment:         sql.NullString{},
+			DataType:        "bigint",
+			DDLType:         "bigserial",
+			NotNull:         true,
+			IsPrimaryKey:    true,
+			Default:         "nextval('customer_id_seq'::regclass)",
+			VolatileDefault: true,
+			TypeSchema:      "pg_catalog",
+			TypeName:        "int8",
 		},
 		&Column{
 			FieldOrdinal: 2,
@@ -65,6 +78,8 @@ func TestLoadColumnDef(t *testing.T) {
 			DDLType:      "text",
 			NotNull:      true,
 			IsPrimaryKey: false,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "text",
 		},
 		&Column{
 			FieldOrdinal: 3,
@@ -74,6 +89,8 @@ func TestLoadColumnDef(t *testing.T) {
 			DDLType:      "text",
 			NotNull:      true,
 			IsPrimaryKey: false,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "text",
 		},
 		&Column{
 			FieldOrdinal: 4,
@@ -83,6 +100,8 @@ func TestLoadColumnDef(t *testing.T) {
 			DDLType:      "text",
 			NotNull:      true,
 			IsPrimaryKey: false,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "text",
 		},
 		&Column{
 			FieldOrdinal: 5,
@@ -92,6 +111,8 @@ func TestLoadColumnDef(t *testing.T) {
 			DDLType:      "text",
 			NotNull:      true,
 			IsPrimaryKey: false,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "text",
 		},
 		&Column{
 			FieldOrdinal: 6,
@@ -101,8 +122,97 @@ func TestLoadColumnDef(t *testing.T) {
 			DDLType:      "timestamp with time zone",
 			NotNull:      true,
 			IsPrimaryKey: false,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "timestamptz",
+		},
+	}
+	for i := range cols {
+		if !reflect.DeepEqual(cols[i], expected[i]) {
+			t.Errorf("\n%+v\n%+v", cols[i], expected[i])
+		}
+	}
+}
+
+func TestLoadColumnDefAutoGenerated(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	cols, err := LoadColumnDef(conn, "public", "payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Column{
+		{
+			FieldOrdinal: 1,
+			Name:         "id",
+			DataType:     "bigint",
+			DDLType:      "bigint",
+			NotNull:      true,
+			IsPrimaryKey: true,
+			Identity:     "ALWAYS",
+			TypeSchema:   "pg_catalog",
+			TypeName:     "int8",
+		},
+		{
+			FieldOrdinal:    2,
+			Name:            "payment_key",
+			DataType:        "uuid",
+			DDLType:         "uuid",
+			NotNull:         true,
+			Default:         "gen_random_uuid()",
+			VolatileDefault: true,
+			TypeSchema:      "pg_catalog",
+			TypeName:        "uuid",
+		},
+		{
+			FieldOrdinal: 3,
+			Name:         "customer_order_id",
+			DataType:     "bigint",
+			DDLType:      "bigint",
+			NotNull:      true,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "int8",
+		},
+		{
+			FieldOrdinal: 4,
+			Name:         "amount",
+			DataType:     "numeric",
+			DDLType:      "numeric",
+			NotNull:      true,
+			TypeSchema:   "pg_catalog",
+			TypeName:     "numeric",
+		},
+		{
+			FieldOrdinal: 5,
+			Name:         "tax_amount",
+			DataType:     "numeric",
+			DDLType:      "numeric",
+			Generated:    "(amount * 0.1)",
+			TypeSchema:   "pg_catalog",
+			TypeName:     "numeric",
+		},
+		{
+			FieldOrdinal: 6,
+			Name:         "paid_at",
+			DataType:     "timestamp with time zone",
+			DDLType:      "timestamp with time zone",
+			NotNull:      true,
+			Default:      "now()",
+			TypeSchema:   "pg_catalog",
+			TypeName:     "timestamptz",
+		},
+		{
+			FieldOrdinal: 7,
+			Name:         "coupon_id",
+			DataType:     "bigint",
+			DDLType:      "bigint",
+			TypeSchema:   "pg_catalog",
+			TypeName:     "int8",
 		},
 	}
+	if len(cols) != len(expected) {
+		t.Fatalf("want %d columns got %d", len(expected), len(cols))
+	}
 	for i := range cols {
 		if !reflect.DeepEqual(cols[i], expected[i]) {
 			t.Errorf("\n%+v\n%+v", cols[i], expected[i])
@@ -110,6 +220,39 @@ func TestLoadColumnDef(t *testing.T) {
 	}
 }
 
+func TestLoadForeignKeyOptions(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	tbls, err := LoadTableDef(conn, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, found := FindTableByName(tbls, "payment")
+	if !found {
+		t.Fatal("payment not found")
+	}
+	fks, err := LoadForeignKeyDef(conn, "public", tbls, payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"payment_coupon_id_fkey coupon_id -> coupon.id NO ACTION NO ACTION true true true",
+		"payment_customer_order_id_fkey customer_order_id -> customer_order.id CASCADE NO ACTION false false false",
+	}
+	for name, loaded := range map[string][]*ForeignKey{"schema": payment.ForeingKeys, "table": fks} {
+		var got []string
+		for _, fk := range loaded {
+			got = append(got, fmt.Sprintf("%s %s -> %s.%s %s %s %t %t %t",
+				fk.ConstraintName, fk.SourceColName, fk.TargetTableName, fk.TargetColName,
+				fk.OnDelete, fk.OnUpdate, fk.IsDeferrable, fk.IsInitiallyDeferred, fk.NotValid))
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: want\n%s\ngot\n%s", name, strings.Join(expected, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
 func TestFindTableByName(t *testing.T) {
 	tbls := []*Table{
 		&Table{Name: "t1"},
@@ -125,6 +268,74 @@ func TestFindTableByName(t *testing.T) {
 	}
 }
 
+func TestFindTableBySchemaName(t *testing.T) {
+	tbls := []*Table{
+		{Schema: "core", Name: "t1"},
+		{Schema: "billing", Name: "t1"},
+	}
+	tbl, found := FindTableBySchemaName(tbls, "billing", "t1")
+	if !found {
+		t.Fatal("billing.t1 not found")
+	}
+	if tbl != tbls[1] {
+		t.Errorf("want %+v got %+v", tbls[1], tbl)
+	}
+	if _, found := FindTableBySchemaName(tbls, "public", "t1"); found {
+		t.Error("public.t1 found")
+	}
+}
+
+func TestMatchSchemas(t *testing.T) {
+	schemas := []string{"billing", "billing_archive", "core", "public"}
+	matched, err := matchSchemas(schemas, "billing*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(matched, []string{"billing", "billing_archive"}) {
+		t.Errorf("want %v got %v", []string{"billing", "billing_archive"}, matched)
+	}
+	if _, err := matchSchemas(schemas, "[billing"); err == nil {
+		t.Error("want error got nil")
+	}
+}
+
+func TestListSchemasContext(t *testing.T) {
+	schemas, err := ListSchemasContext(context.Background(), nil, []string{"core", "billing", "core"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(schemas, []string{"core", "billing"}) {
+		t.Errorf("want %v got %v", []string{"core", "billing"}, schemas)
+	}
+}
+
+func TestListSchemasContextNoMatch(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	_, err := ListSchemasContext(context.Background(), conn, []string{"no_such_schema_*"})
+	if err == nil || err.Error() != "no schema matches no_such_schema_*" {
+		t.Errorf("want no schema matches error got %v", err)
+	}
+}
+
+func TestIsLoadedSchema(t *testing.T) {
+	tbls := []*Table{{Schema: "app", Name: "t1"}}
+	cases := []struct {
+		targetSchema string
+		expected     bool
+	}{
+		{targetSchema: "", expected: true},
+		{targetSchema: "app", expected: true},
+		{targetSchema: "public", expected: false},
+	}
+	for _, c := range cases {
+		if got := isLoadedSchema(tbls, "app", c.targetSchema); got != c.expected {
+			t.Errorf("%s: want %t got %t", c.targetSchema, c.expected, got)
+		}
+	}
+}
+
 func TestLoadForeignKeyDef(t *testing.T) {
 	conn, cleanup := testPgSetup(t)
 	defer cleanup()
@@ -197,6 +408,79 @@ func TestLoadTableDef(t *testing.T) {
 	}
 }
 
+func TestLoadTableDefBatch(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	schema := "public"
+	tbls, err := LoadTableDef(conn, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tbl := range tbls {
+		cols, err := LoadColumnDef(conn, schema, tbl.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cols) != len(tbl.Columns) {
+			t.Fatalf("%s: want %d got %d", tbl.Name, len(cols), len(tbl.Columns))
+		}
+		for i := range cols {
+			c := *tbl.Columns[i]
+			c.IsForeignKey = false
+			c.IsUniqueKey = false
+			if !reflect.DeepEqual(&c, cols[i]) {
+				t.Errorf("\n%+v\n%+v", &c, cols[i])
+			}
+		}
+		fks, err := LoadForeignKeyDef(conn, schema, tbls, tbl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fks) != len(tbl.ForeingKeys) {
+			t.Fatalf("%s: want %d got %d", tbl.Name, len(fks), len(tbl.ForeingKeys))
+		}
+		for i := range fks {
+			fk, exp := tbl.ForeingKeys[i], fks[i]
+			if fk.ConstraintName != exp.ConstraintName || fk.SourceColName != exp.SourceColName ||
+				fk.TargetTableName != exp.TargetTableName || fk.TargetColName != exp.TargetColName ||
+				fk.SourceColumn != exp.SourceColumn || fk.TargetColumn != exp.TargetColumn {
+				t.Errorf("\n%+v\n%+v", fk, exp)
+			}
+		}
+	}
+}
+
+func TestLoadTableDefContext(t *testing.T) {
+	db, err := OpenDB(testDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadTableDefContext(ctx, db, "public"); errors.Cause(err) != context.Canceled {
+		t.Errorf("want %s got %v", context.Canceled, err)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	db, err := OpenDB(testDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if q, ok := withContext(db).(*sql.DB); !ok || q != db {
+		t.Errorf("want *sql.DB got %T", q)
+	}
+	tx := &sql.Tx{}
+	if _, ok := withContext(struct{ Queryer }{tx}).(noContextQueryer); !ok {
+		t.Error("want noContextQueryer")
+	}
+}
+
 func TestTableToUMLEntry(t *testing.T) {
 	conn, cleanup := testPgSetup(t)
 	defer cleanup()
@@ -224,13 +508,102 @@ func TestForeignKeyToUMLRelation(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	buf, err := ForeignKeyToUMLRelation(tbls)
+	buf, err := ForeignKeyToUMLRelation(tbls, LabelNone)
 	if err != nil {
 		t.Fatal(err)
 	}
 	t.Logf("%s", buf)
 }
 
+func TestForeignKeyToUMLRelationComposite(t *testing.T) {
+	buf, err := ForeignKeyToUMLRelation(testTables(), LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(buf)
+	rel := `"**order_detail_approval**" |o-|| "**order_detail**"`
+	if n := strings.Count(src, rel); n != 1 {
+		t.Errorf("want %d got %d: %q in\n%s", 1, n, rel, src)
+	}
+}
+
+func TestFilterTablesQualified(t *testing.T) {
+	cases := []struct {
+		filters  []string
+		expected []string
+	}{
+		{filters: []string{"^customer$"}, expected: []string{"public.customer", "billing.customer"}},
+		{filters: []string{"public\\.customer$"}, expected: []string{"public.customer"}},
+		{filters: []string{"^billing\\."}, expected: []string{"billing.customer", "billing.invoice"}},
+		{filters: []string{"billing"}, expected: nil},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, tbl := range FilterTables(true, testTables(withSchemas), c.filters) {
+			got = append(got, tbl.Schema+"."+tbl.Name)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%v: want %v got %v", c.filters, c.expected, got)
+		}
+	}
+
+	tbls := FilterTables(false, testTables(withSchemas), []string{"public\\.customer$"})
+	if invoice := tbls[len(tbls)-1]; len(invoice.ForeingKeys) != 0 {
+		t.Errorf("want fk to public.customer excluded got %+v", invoice.ForeingKeys)
+	}
+}
+
+func TestTableToPlantUMLMultiSchema(t *testing.T) {
+	tbls := FilterTables(true, testTables(withSchemas), []string{"^customer$", "invoice"})
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+set namespaceSeparator none
+
+package "public" {
+
+entity "**public.customer**" {
+  Customer Information
+  ..
+  + ""id"": //bigserial [PK]//
+  --
+  *""name"": //text  : Customer Name//
+  *""registered_at"": //timestamp with time zone //
+}
+}
+
+package "billing" {
+
+entity "**billing.customer**" {
+  + ""id"": //bigint [PK]//
+  --
+}
+
+entity "**billing.invoice**" {
+  + ""id"": //bigint [PK]//
+  --
+  *""customer_id"": //bigint [FK]//
+}
+}
+
+"**billing.invoice**" }o--|| "**public.customer**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+
+	invoice := tbls[2]
+	invoice.ForeingKeys[0].TargetSchema = "core"
+	if err := resolveForeignKeys(tbls, invoice.ForeingKeys); err == nil {
+		t.Error("want error got nil")
+	}
+}
+
 func TestFilterTables(t *testing.T) {
 	tables := []*Table{
 		{Name: "table1"}, {Name: "table2"},
@@ -400,3 +773,541 @@ func TestFilterTables(t *testing.T) {
 		})
 	})
 }
+
+// testForeignKey builds fk whose table and column references are resolved by testTables
+func testForeignKey(name string, src *Table, srcCols []string, dst *Table, dstCols []string) *ForeignKey {
+	fk := &ForeignKey{
+		ConstraintName:  name,
+		SourceTableName: src.Name,
+		SourceColName:   srcCols[0],
+		SourceTable:     src,
+		TargetSchema:    dst.Schema,
+		TargetTableName: dst.Name,
+		TargetColName:   dstCols[0],
+		TargetTable:     dst,
+	}
+	for i := range srcCols {
+		fk.Columns = append(fk.Columns, &ForeignKeyColumn{
+			SourceColName: srcCols[i],
+			TargetColName: dstCols[i],
+		})
+	}
+	src.ForeingKeys = append(src.ForeingKeys, fk)
+	return fk
+}
+
+// testOption extends tables built by testTables
+type testOption func(tbls []*Table) []*Table
+
+// testTables builds a subset of example/ddl.sql in memory so that
+// renderers can be tested without database. opts add constraints, types,
+// views, partitions or tables of another schema, or change the tables.
+func testTables(opts ...testOption) []*Table {
+	customer := &Table{
+		Schema:  "public",
+		Name:    "customer",
+		Comment: sql.NullString{String: "Customer Information", Valid: true},
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigserial", NotNull: true, IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "name", DataType: "text", DDLType: "text", NotNull: true,
+				Comment: sql.NullString{String: "Customer Name", Valid: true}},
+			{FieldOrdinal: 3, Name: "registered_at", DataType: "timestamp with time zone",
+				DDLType: "timestamp with time zone", NotNull: true},
+		},
+	}
+	order := &Table{
+		Schema: "public",
+		Name:   "customer_order",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigserial", NotNull: true, IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "customer_id", DataType: "bigint", DDLType: "bigint", NotNull: true},
+		},
+	}
+	detail := &Table{
+		Schema: "public",
+		Name:   "order_detail",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigserial", NotNull: true, IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "customer_order_id", DataType: "bigint", DDLType: "bigint", NotNull: true,
+				IsPrimaryKey: true},
+			{FieldOrdinal: 3, Name: "amount", DataType: "bigint", DDLType: "bigint", NotNull: true},
+		},
+	}
+	approval := &Table{
+		Schema: "public",
+		Name:   "order_detail_approval",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "order_detail_id", DataType: "bigint", DDLType: "bigint", NotNull: true,
+				IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "customer_order_id", DataType: "bigint", DDLType: "bigint", NotNull: true,
+				IsPrimaryKey: true},
+		},
+	}
+	testForeignKey("customer_order_customer_id_fkey",
+		order, []string{"customer_id"}, customer, []string{"id"})
+	testForeignKey("order_detail_customer_order_id_fkey",
+		detail, []string{"customer_order_id"}, order, []string{"id"})
+	testForeignKey("order_detail_approval_order_detail_id_customer_order_id_fkey",
+		approval, []string{"order_detail_id", "customer_order_id"}, detail, []string{"id", "customer_order_id"})
+	tbls := []*Table{customer, order, detail, approval}
+	for _, tbl := range tbls {
+		if err := resolveForeignKeys(tbls, tbl.ForeingKeys); err != nil {
+			panic(err)
+		}
+	}
+	for _, opt := range opts {
+		tbls = opt(tbls)
+	}
+	return tbls
+}
+
+// withConstraints adds primary key, unique and check constraints to customer
+func withConstraints(tbls []*Table) []*Table {
+	customer := tbls[0]
+	customer.Constraints = []*Constraint{
+		{Name: "customer_pkey", Type: ConstraintTypePrimaryKey, ColumnNames: []string{"id"},
+			Definition: "PRIMARY KEY (id)"},
+		{Name: "customer_name_key", Type: ConstraintTypeUnique, ColumnNames: []string{"name"},
+			Definition: "UNIQUE (name)"},
+		{Name: "customer_registered_at_check", Type: ConstraintTypeCheck, ColumnNames: []string{"registered_at"},
+			Definition: "CHECK ((registered_at > '2000-01-01 00:00:00+00'::timestamp with time zone))"},
+	}
+	setUniqueKeys(customer)
+	return tbls
+}
+
+// withTypes adds columns typed with enum and domain types to customer_order
+func withTypes(tbls []*Table) []*Table {
+	status := &TypeDef{
+		Schema: "public",
+		Name:   "order_status",
+		Kind:   TypeKindEnum,
+		Labels: []string{"ordered", "shipped", "delivered"},
+	}
+	price := &TypeDef{
+		Schema:   "public",
+		Name:     "price",
+		Kind:     TypeKindDomain,
+		BaseType: "numeric",
+		NotNull:  true,
+		Checks:   []string{"CHECK (VALUE >= 0)"},
+	}
+	order := tbls[1]
+	order.Columns = append(order.Columns,
+		&Column{FieldOrdinal: 3, Name: "status", DataType: "order_status", DDLType: "order_status", NotNull: true,
+			TypeSchema: "public", TypeName: "order_status"},
+		&Column{FieldOrdinal: 4, Name: "total_price", DataType: "price", DDLType: "price", NotNull: true,
+			TypeSchema: "public", TypeName: "price"},
+	)
+	LinkTypeDefs(tbls, []*TypeDef{status, price})
+	return tbls
+}
+
+// withViews adds materialized view depending on customer and customer_order
+func withViews(tbls []*Table) []*Table {
+	summary := &Table{
+		Schema: "public",
+		Name:   "customer_summary",
+		Kind:   TableKindMaterializedView,
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "customer_id", DataType: "bigint", DDLType: "bigint"},
+			{FieldOrdinal: 2, Name: "order_count", DataType: "bigint", DDLType: "bigint"},
+		},
+	}
+	for _, t := range tbls[:2] {
+		summary.Dependencies = append(summary.Dependencies, &ViewDependency{
+			ViewName:        summary.Name,
+			View:            summary,
+			TargetSchema:    t.Schema,
+			TargetTableName: t.Name,
+			TargetTable:     t,
+		})
+	}
+	return append(tbls, summary)
+}
+
+// withPartitions adds events partitioned by range and its two partitions
+func withPartitions(tbls []*Table) []*Table {
+	events := &Table{
+		Schema:       "public",
+		Name:         "events",
+		Kind:         TableKindTable,
+		PartitionKey: "RANGE (created_at)",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigint", NotNull: true},
+			{FieldOrdinal: 2, Name: "created_at", DataType: "timestamp with time zone",
+				DDLType: "timestamp with time zone", NotNull: true},
+		},
+	}
+	tbls = append(tbls, events)
+	for _, b := range []struct{ name, bound string }{
+		{name: "events_2024_01", bound: "FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')"},
+		{name: "events_2024_02", bound: "FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')"},
+	} {
+		tbls = append(tbls, &Table{
+			Schema:                "public",
+			Name:                  b.name,
+			Kind:                  TableKindTable,
+			Columns:               events.Columns,
+			PartitionBound:        b.bound,
+			PartitionParentSchema: "public",
+			PartitionParentName:   "events",
+			PartitionParent:       events,
+		})
+	}
+	return tbls
+}
+
+// withSchemas adds billing.customer of the same name as public.customer, and
+// billing.invoice referencing public.customer
+func withSchemas(tbls []*Table) []*Table {
+	customer := &Table{
+		Schema:  "billing",
+		Name:    "customer",
+		Columns: []*Column{{Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true}},
+	}
+	invoice := &Table{
+		Schema: "billing",
+		Name:   "invoice",
+		Columns: []*Column{
+			{Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true},
+			{Name: "customer_id", DDLType: "bigint", NotNull: true},
+		},
+	}
+	testForeignKey("invoice_customer_id_fkey", invoice, []string{"customer_id"}, tbls[0], []string{"id"})
+	tbls = append(tbls, customer, invoice)
+	if err := resolveForeignKeys(tbls, invoice.ForeingKeys); err != nil {
+		panic(err)
+	}
+	return tbls
+}
+
+// withChanges changes customer and customer_order, drops order_detail_approval
+// and adds invoice
+func withChanges(tbls []*Table) []*Table {
+	customer := tbls[0]
+	customer.Comment = sql.NullString{String: "Customers", Valid: true}
+	customer.Columns[1].DDLType = "character varying(100)"
+	customer.Columns = append(customer.Columns[:2], &Column{
+		FieldOrdinal: 4, Name: "email", DataType: "text", DDLType: "text", NotNull: true})
+	tbls[1].ForeingKeys[0].OnDelete = ForeignKeyActionCascade
+	return append(tbls[:3], &Table{
+		Schema:  "public",
+		Name:    "invoice",
+		Kind:    TableKindTable,
+		Columns: []*Column{{FieldOrdinal: 1, Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true}},
+	})
+}
+
+func TestForeignKeyCardinality(t *testing.T) {
+	tbls := testTables()
+	profile := &Table{
+		Schema: "public",
+		Name:   "customer_profile",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigserial", NotNull: true, IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "customer_id", DataType: "bigint", DDLType: "bigint"},
+		},
+		Constraints: []*Constraint{
+			{Name: "customer_profile_customer_id_key", Type: ConstraintTypeUnique, ColumnNames: []string{"customer_id"}},
+		},
+	}
+	testForeignKey("customer_profile_customer_id_fkey",
+		profile, []string{"customer_id"}, tbls[0], []string{"id"})
+	// unique indexes do not affect cardinality since they are loaded only with --indexes
+	avatar := &Table{
+		Schema: "public",
+		Name:   "customer_avatar",
+		Columns: []*Column{
+			{FieldOrdinal: 1, Name: "id", DataType: "bigint", DDLType: "bigserial", NotNull: true, IsPrimaryKey: true},
+			{FieldOrdinal: 2, Name: "customer_id", DataType: "bigint", DDLType: "bigint", NotNull: true},
+		},
+		Indexes: []*Index{
+			{Name: "customer_avatar_customer_id_idx", Columns: []string{"customer_id"}, IsUnique: true, Method: "btree"},
+		},
+		IndexesLoaded: true,
+	}
+	testForeignKey("customer_avatar_customer_id_fkey",
+		avatar, []string{"customer_id"}, tbls[0], []string{"id"})
+	tbls = append(tbls, profile, avatar)
+	for _, tbl := range []*Table{profile, avatar} {
+		if err := resolveForeignKeys(tbls, tbl.ForeingKeys); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		tbl            *Table
+		source, target Cardinality
+		arrow          string
+	}{
+		{tbl: tbls[1], source: CardinalityZeroOrMore, target: CardinalityExactlyOne, arrow: "}o--||"},
+		{tbl: tbls[2], source: CardinalityZeroOrMore, target: CardinalityExactlyOne, arrow: "}o--||"},
+		{tbl: tbls[3], source: CardinalityZeroOrOne, target: CardinalityExactlyOne, arrow: "|o-||"},
+		{tbl: profile, source: CardinalityZeroOrOne, target: CardinalityZeroOrOne, arrow: "|o-o|"},
+		{tbl: avatar, source: CardinalityZeroOrMore, target: CardinalityExactlyOne, arrow: "}o--||"},
+	}
+	for _, c := range cases {
+		fk := c.tbl.ForeingKeys[0]
+		source, target := fk.Cardinality()
+		if source != c.source || target != c.target {
+			t.Errorf("%s: want %d, %d got %d, %d", fk.ConstraintName, c.source, c.target, source, target)
+		}
+		if got := umlRelationArrow(fk); got != c.arrow {
+			t.Errorf("%s: want %s got %s", fk.ConstraintName, c.arrow, got)
+		}
+	}
+}
+
+func TestAppendForeignKeyRow(t *testing.T) {
+	rows := []*ForeignKey{
+		{ConstraintName: "a_fkey", SourceTableName: "t", SourceColName: "c1", TargetColName: "d1"},
+		{ConstraintName: "a_fkey", SourceTableName: "t", SourceColName: "c2", TargetColName: "d2"},
+		{ConstraintName: "b_fkey", SourceTableName: "t", SourceColName: "c3", TargetColName: "d3"},
+	}
+	var fks []*ForeignKey
+	for _, r := range rows {
+		fks = appendForeignKeyRow(fks, r)
+	}
+	if len(fks) != 2 {
+		t.Fatalf("want %d got %d", 2, len(fks))
+	}
+	if got := fks[0].SourceColNames(); !reflect.DeepEqual(got, []string{"c1", "c2"}) {
+		t.Errorf("want %v got %v", []string{"c1", "c2"}, got)
+	}
+	if got := fks[0].TargetColNames(); !reflect.DeepEqual(got, []string{"d1", "d2"}) {
+		t.Errorf("want %v got %v", []string{"d1", "d2"}, got)
+	}
+	if got := fks[1].SourceColNames(); !reflect.DeepEqual(got, []string{"c3"}) {
+		t.Errorf("want %v got %v", []string{"c3"}, got)
+	}
+}
+
+func TestColumnDefaultClause(t *testing.T) {
+	cases := []struct {
+		col      *Column
+		expected string
+	}{
+		{col: &Column{DDLType: "bigserial", Default: "nextval('customer_id_seq'::regclass)"}, expected: ""},
+		{col: &Column{DDLType: "bigint", Identity: "ALWAYS"}, expected: "GENERATED ALWAYS AS IDENTITY"},
+		{col: &Column{DDLType: "bigint", Generated: "price * quantity"},
+			expected: "GENERATED ALWAYS AS (price * quantity) STORED"},
+		{col: &Column{DDLType: "text", Default: "'new'::text"}, expected: "DEFAULT 'new'::text"},
+		{col: &Column{DDLType: "text"}, expected: ""},
+	}
+	for _, c := range cases {
+		if got := c.col.DefaultClause(); got != c.expected {
+			t.Errorf("want %q got %q", c.expected, got)
+		}
+	}
+}
+
+func TestSetAutoGenPk(t *testing.T) {
+	tbls := testTables()
+	for _, tbl := range tbls {
+		for _, c := range tbl.Columns {
+			if c.IsSerial() {
+				c.Default = "nextval('" + tbl.Name + "_id_seq'::regclass)"
+			}
+		}
+		setAutoGenPk(tbl)
+	}
+	for i, expected := range []bool{true, true, true, false} {
+		if tbls[i].AutoGenPk != expected {
+			t.Errorf("%s: want %t got %t", tbls[i].Name, expected, tbls[i].AutoGenPk)
+		}
+	}
+
+	cases := []struct {
+		col      *Column
+		expected bool
+	}{
+		{col: &Column{DDLType: "text", Default: "'x'::text"}, expected: false},
+		{col: &Column{DDLType: "integer", Default: "0"}, expected: false},
+		{col: &Column{DDLType: "uuid", Default: "gen_random_uuid()", VolatileDefault: true}, expected: true},
+		{col: &Column{DDLType: "bigint", Identity: "BY DEFAULT"}, expected: true},
+	}
+	for _, c := range cases {
+		c.col.IsPrimaryKey = true
+		tbl := &Table{Name: "t", Columns: []*Column{c.col}}
+		setAutoGenPk(tbl)
+		if tbl.AutoGenPk != c.expected {
+			t.Errorf("%+v: want %t got %t", c.col, c.expected, tbl.AutoGenPk)
+		}
+	}
+
+	order := tbls[1]
+	order.Columns = append(order.Columns,
+		&Column{FieldOrdinal: 3, Name: "status", DataType: "text", DDLType: "text", NotNull: true,
+			Default: "'ordered'::text"})
+	buf, err := TableToUMLEntry(tbls[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+entity "**customer_order**" {
+  + ""id"": //bigserial [PK]//
+  --
+  *""customer_id"": //bigint [FK]//
+  *""status"": //text DEFAULT 'ordered'::text //
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestForeignKeyActionLabel(t *testing.T) {
+	cases := []struct {
+		fk       *ForeignKey
+		expected string
+	}{
+		{fk: &ForeignKey{OnDelete: ForeignKeyActionNoAction, OnUpdate: ForeignKeyActionNoAction}, expected: ""},
+		{fk: &ForeignKey{OnDelete: ForeignKeyActionCascade, OnUpdate: ForeignKeyActionNoAction},
+			expected: "ON DELETE CASCADE"},
+		{fk: &ForeignKey{OnDelete: ForeignKeyActionSetNull, OnUpdate: ForeignKeyActionCascade},
+			expected: "ON DELETE SET NULL ON UPDATE CASCADE"},
+		{fk: &ForeignKey{IsDeferrable: true, IsInitiallyDeferred: true, NotValid: true},
+			expected: "DEFERRABLE INITIALLY DEFERRED NOT VALID"},
+	}
+	for _, c := range cases {
+		if got := c.fk.ActionLabel(); got != c.expected {
+			t.Errorf("want %q got %q", c.expected, got)
+		}
+	}
+}
+
+func TestForeignKeyToUMLRelationAction(t *testing.T) {
+	tbls := testTables()
+	tbls[1].ForeingKeys[0].OnDelete = ForeignKeyActionCascade
+	tbls[3].ForeingKeys[0].NotValid = true
+	buf, err := ForeignKeyToUMLRelation(tbls, LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+"**customer_order**" }o-[#red]-|| "**customer**" : ON DELETE CASCADE
+
+"**order_detail**" }o--|| "**customer_order**"
+
+"**order_detail_approval**" |o-[dashed]|| "**order_detail**" : NOT VALID
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestRelationLabel(t *testing.T) {
+	account := &Table{
+		Schema:  "public",
+		Name:    "account",
+		Columns: []*Column{{Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true}},
+	}
+	employee := &Table{
+		Schema: "public",
+		Name:   "employee",
+		Columns: []*Column{
+			{Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true},
+			{Name: "manager_id", DDLType: "bigint"},
+		},
+	}
+	transfer := &Table{
+		Schema: "public",
+		Name:   "transfer",
+		Columns: []*Column{
+			{Name: "id", DDLType: "bigint", NotNull: true, IsPrimaryKey: true},
+			{Name: "from_account_id", DDLType: "bigint", NotNull: true},
+			{Name: "to_account_id", DDLType: "bigint", NotNull: true},
+		},
+	}
+	testForeignKey("employee_manager_id_fkey", employee, []string{"manager_id"}, employee, []string{"id"})
+	testForeignKey("transfer_from_account_id_fkey", transfer, []string{"from_account_id"}, account, []string{"id"})
+	fk := testForeignKey("transfer_to_account_id_fkey", transfer, []string{"to_account_id"}, account, []string{"id"})
+	fk.OnDelete = ForeignKeyActionCascade
+	tbls := []*Table{account, employee, transfer}
+	for _, tbl := range tbls {
+		if err := resolveForeignKeys(tbls, tbl.ForeingKeys); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		name     string
+		mode     string
+		render   func([]*Table, string) ([]byte, error)
+		expected string
+	}{
+		{name: "plantuml", mode: LabelNone, render: ForeignKeyToUMLRelation, expected: `
+"**employee**" }o--o| "**employee**"
+
+"**transfer**" }o--|| "**account**"
+
+"**transfer**" }o-[#red]-|| "**account**" : ON DELETE CASCADE
+`},
+		{name: "plantuml", mode: LabelColumn, render: ForeignKeyToUMLRelation, expected: `
+"**employee**" }o--o| "**employee**" : manager_id
+
+"**transfer**" }o--|| "**account**" : from_account_id
+
+"**transfer**" }o-[#red]-|| "**account**" : to_account_id ON DELETE CASCADE
+`},
+		{name: "plantuml", mode: LabelConstraint, render: ForeignKeyToUMLRelation, expected: `
+"**employee**" }o--o| "**employee**" : employee_manager_id_fkey
+
+"**transfer**" }o--|| "**account**" : transfer_from_account_id_fkey
+
+"**transfer**" }o-[#red]-|| "**account**" : transfer_to_account_id_fkey ON DELETE CASCADE
+`},
+		{name: "dot", mode: LabelColumn, render: ForeignKeyToDotEdge, expected: `
+  "employee":"manager_id" -> "employee":"id" [arrowtail=crowodot, arrowhead=teeodot, label="manager_id"];
+
+  "transfer":"from_account_id" -> "account":"id" [arrowtail=crowodot, arrowhead=teetee, label="from_account_id"];
+
+  "transfer":"to_account_id" -> "account":"id" [arrowtail=crowodot, arrowhead=teetee, label="to_account_id"];
+`},
+		{name: "mermaid", mode: LabelColumn, render: ForeignKeyToMermaidRelation, expected: `
+  employee }o--o| employee : "manager_id"
+
+  transfer }o--|| account : "from_account_id"
+
+  transfer }o--|| account : "to_account_id"
+`},
+		{name: "mermaid", mode: LabelNone, render: ForeignKeyToMermaidRelation, expected: `
+  employee }o--o| employee : ""
+
+  transfer }o--|| account : ""
+
+  transfer }o--|| account : ""
+`},
+	}
+	for _, c := range cases {
+		buf, err := c.render(tbls, c.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src := string(buf); src != c.expected {
+			t.Errorf("%s %s: want\n%s\ngot\n%s", c.name, c.mode, c.expected, src)
+		}
+	}
+
+	// quotes in labels are escaped
+	employee.ForeingKeys[0].ConstraintName = `employee "manager"`
+	buf, err := ForeignKeyToDotEdge(tbls[1:2], LabelConstraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+  "employee":"manager_id" -> "employee":"id" [arrowtail=crowodot, arrowhead=teeodot, label="employee \"manager\""];
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+	buf, err = ForeignKeyToMermaidRelation(tbls[1:2], LabelConstraint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `
+  employee }o--o| employee : "employee 'manager'"
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
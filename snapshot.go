@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotVersion version of snapshot format. It is incremented whenever
+// a field is removed or its meaning changes. Adding fields doesn't change it.
+const SnapshotVersion = 2
+
+// Snapshot machine readable representation of loaded tables
+//
+// Version 2 format (JSON, YAML uses the same keys):
+//
+//	{
+//	  "version": 2,
+//	  "title": "optional diagram title",
+//	  "tables": [{
+//	    "schema": "public",
+//	    "name": "customer",
+//	    "kind": "table, view or materialized view",
+//	    "comment": "table comment or null",
+//	    "auto_gen_pk": false,
+//	    "columns": [{
+//	      "field_ordinal": 1,
+//	      "name": "id",
+//	      "comment": "column comment or null",
+//	      "data_type": "bigint",
+//	      "ddl_type": "bigserial",
+//	      "not_null": true,
+//	      "is_primary_key": true,
+//	      "is_foreign_key": false,
+//	      "is_unique_key": false,
+//	      "default": "default expression, omitted if none",
+//	      "volatile_default": true,
+//	      "identity": "ALWAYS or BY DEFAULT for identity column, omitted otherwise",
+//	      "generated": "expression of generated column, omitted otherwise",
+//	      "type_schema": "schema of enum or domain type, omitted otherwise",
+//	      "type_name": "name of enum or domain type, omitted otherwise"
+//	    }],
+//	    "constraints": [{
+//	      "name": "customer_email_key",
+//	      "type": "PRIMARY KEY, UNIQUE, CHECK or EXCLUDE",
+//	      "columns": ["email"],
+//	      "definition": "UNIQUE (email)"
+//	    }],
+//	    "indexes_loaded": true,
+//	    "indexes": [{
+//	      "name": "customer_order_customer_id_idx",
+//	      "columns": ["column names or expressions"],
+//	      "is_unique": false,
+//	      "is_primary": false,
+//	      "predicate": "WHERE clause of partial index, omitted otherwise",
+//	      "method": "btree"
+//	    }],
+//	    "foreign_keys": [{
+//	      "constraint_name": "customer_order_customer_id_fkey",
+//	      "source_table": "customer_order",
+//	      "target_schema": "public",
+//	      "target_table": "customer",
+//	      "on_delete": "CASCADE, SET NULL, SET DEFAULT or RESTRICT, omitted if NO ACTION",
+//	      "on_update": "same as on_delete",
+//	      "deferrable": false,
+//	      "initially_deferred": false,
+//	      "not_valid": false,
+//	      "columns": [{
+//	        "source_column": "customer_id",
+//	        "is_source_column_primary_key": false,
+//	        "target_column": "id",
+//	        "is_target_column_primary_key": true
+//	      }]
+//	    }],
+//	    "dependencies": [{
+//	      "target_schema": "public",
+//	      "target_table": "customer"
+//	    }],
+//	    "partition_key": "RANGE (created_at)",
+//	    "partition_bound": "FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')",
+//	    "partition_parent_schema": "public",
+//	    "partition_parent_table": "events"
+//	  }],
+//	  "types": [{
+//	    "schema": "public",
+//	    "name": "order_status",
+//	    "kind": "enum or domain",
+//	    "comment": "type comment or null",
+//	    "labels": ["labels of enum"],
+//	    "base_type": "base type of domain",
+//	    "not_null": false,
+//	    "checks": ["CHECK constraints of domain"]
+//	  }]
+//	}
+type Snapshot struct {
+	Version int              `json:"version" yaml:"version"`
+	Title   string           `json:"title,omitempty" yaml:"title,omitempty"`
+	Tables  []*SnapshotTable `json:"tables" yaml:"tables"`
+	Types   []*SnapshotType  `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// SnapshotType enum or domain type used by columns in snapshot
+type SnapshotType struct {
+	Schema   string   `json:"schema" yaml:"schema"`
+	Name     string   `json:"name" yaml:"name"`
+	Kind     string   `json:"kind" yaml:"kind"`
+	Comment  *string  `json:"comment" yaml:"comment"`
+	Labels   []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	BaseType string   `json:"base_type,omitempty" yaml:"base_type,omitempty"`
+	NotNull  bool     `json:"not_null,omitempty" yaml:"not_null,omitempty"`
+	Checks   []string `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+// SnapshotTable table in snapshot
+type SnapshotTable struct {
+	Schema        string                `json:"schema" yaml:"schema"`
+	Name          string                `json:"name" yaml:"name"`
+	Kind          string                `json:"kind" yaml:"kind"`
+	Comment       *string               `json:"comment" yaml:"comment"`
+	AutoGenPk     bool                  `json:"auto_gen_pk" yaml:"auto_gen_pk"`
+	Columns       []*SnapshotColumn     `json:"columns" yaml:"columns"`
+	Constraints   []*SnapshotConstraint `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Indexes       []*SnapshotIndex      `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	IndexesLoaded bool                  `json:"indexes_loaded,omitempty" yaml:"indexes_loaded,omitempty"`
+	ForeignKeys   []*SnapshotForeignKey `json:"foreign_keys" yaml:"foreign_keys"`
+	Dependencies  []*SnapshotDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+
+	PartitionKey          string `json:"partition_key,omitempty" yaml:"partition_key,omitempty"`
+	PartitionBound        string `json:"partition_bound,omitempty" yaml:"partition_bound,omitempty"`
+	PartitionParentSchema string `json:"partition_parent_schema,omitempty" yaml:"partition_parent_schema,omitempty"`
+	PartitionParentTable  string `json:"partition_parent_table,omitempty" yaml:"partition_parent_table,omitempty"`
+}
+
+// SnapshotConstraint primary key, unique, check or exclusion constraint in snapshot
+type SnapshotConstraint struct {
+	Name       string   `json:"name" yaml:"name"`
+	Type       string   `json:"type" yaml:"type"`
+	Columns    []string `json:"columns,omitempty" yaml:"columns,omitempty"`
+	Definition string   `json:"definition" yaml:"definition"`
+}
+
+// SnapshotIndex index in snapshot
+type SnapshotIndex struct {
+	Name      string   `json:"name" yaml:"name"`
+	Columns   []string `json:"columns" yaml:"columns"`
+	IsUnique  bool     `json:"is_unique" yaml:"is_unique"`
+	IsPrimary bool     `json:"is_primary" yaml:"is_primary"`
+	Predicate string   `json:"predicate,omitempty" yaml:"predicate,omitempty"`
+	Method    string   `json:"method" yaml:"method"`
+}
+
+// SnapshotDependency table or view read by view in snapshot
+type SnapshotDependency struct {
+	TargetSchema string `json:"target_schema" yaml:"target_schema"`
+	TargetTable  string `json:"target_table" yaml:"target_table"`
+}
+
+// SnapshotColumn column in snapshot
+type SnapshotColumn struct {
+	FieldOrdinal    int     `json:"field_ordinal" yaml:"field_ordinal"`
+	Name            string  `json:"name" yaml:"name"`
+	Comment         *string `json:"comment" yaml:"comment"`
+	DataType        string  `json:"data_type" yaml:"data_type"`
+	DDLType         string  `json:"ddl_type" yaml:"ddl_type"`
+	NotNull         bool    `json:"not_null" yaml:"not_null"`
+	IsPrimaryKey    bool    `json:"is_primary_key" yaml:"is_primary_key"`
+	IsForeignKey    bool    `json:"is_foreign_key" yaml:"is_foreign_key"`
+	IsUniqueKey     bool    `json:"is_unique_key,omitempty" yaml:"is_unique_key,omitempty"`
+	Default         string  `json:"default,omitempty" yaml:"default,omitempty"`
+	VolatileDefault bool    `json:"volatile_default,omitempty" yaml:"volatile_default,omitempty"`
+	Identity        string  `json:"identity,omitempty" yaml:"identity,omitempty"`
+	Generated       string  `json:"generated,omitempty" yaml:"generated,omitempty"`
+	TypeSchema      string  `json:"type_schema,omitempty" yaml:"type_schema,omitempty"`
+	TypeName        string  `json:"type_name,omitempty" yaml:"type_name,omitempty"`
+}
+
+// SnapshotForeignKey foreign key in snapshot. Source/target tables are
+// referred by name instead of pointers.
+type SnapshotForeignKey struct {
+	ConstraintName string                      `json:"constraint_name" yaml:"constraint_name"`
+	SourceTable    string                      `json:"source_table" yaml:"source_table"`
+	TargetSchema   string                      `json:"target_schema" yaml:"target_schema"`
+	TargetTable    string                      `json:"target_table" yaml:"target_table"`
+	OnDelete       string                      `json:"on_delete,omitempty" yaml:"on_delete,omitempty"`
+	OnUpdate       string                      `json:"on_update,omitempty" yaml:"on_update,omitempty"`
+	Deferrable     bool                        `json:"deferrable,omitempty" yaml:"deferrable,omitempty"`
+	Deferred       bool                        `json:"initially_deferred,omitempty" yaml:"initially_deferred,omitempty"`
+	NotValid       bool                        `json:"not_valid,omitempty" yaml:"not_valid,omitempty"`
+	Columns        []*SnapshotForeignKeyColumn `json:"columns" yaml:"columns"`
+}
+
+// SnapshotForeignKeyColumn column pair of foreign key in snapshot
+type SnapshotForeignKeyColumn struct {
+	SourceColumn          string `json:"source_column" yaml:"source_column"`
+	IsSourceColPrimaryKey bool   `json:"is_source_column_primary_key" yaml:"is_source_column_primary_key"`
+	TargetColumn          string `json:"target_column" yaml:"target_column"`
+	IsTargetColPrimaryKey bool   `json:"is_target_column_primary_key" yaml:"is_target_column_primary_key"`
+}
+
+func nullStringToPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	v := s.String
+	return &v
+}
+
+func ptrToNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// snapshotAction omit NO ACTION, the default referential action
+func snapshotAction(a string) string {
+	if a == ForeignKeyActionNoAction {
+		return ""
+	}
+	return a
+}
+
+// NewSnapshot create snapshot from tables. Dependencies of views on tables
+// not in tbls are omitted since they cannot be restored.
+func NewSnapshot(tbls []*Table, title string) *Snapshot {
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Title:   title,
+		Tables:  []*SnapshotTable{},
+	}
+	included := make(map[*Table]bool)
+	for _, tbl := range tbls {
+		included[tbl] = true
+	}
+	for _, tbl := range tbls {
+		st := &SnapshotTable{
+			Schema:        tbl.Schema,
+			Name:          tbl.Name,
+			Kind:          string(tbl.Kind),
+			Comment:       nullStringToPtr(tbl.Comment),
+			AutoGenPk:     tbl.AutoGenPk,
+			IndexesLoaded: tbl.IndexesLoaded,
+			Columns:       []*SnapshotColumn{},
+			ForeignKeys:   []*SnapshotForeignKey{},
+		}
+		for _, c := range tbl.Columns {
+			sc := &SnapshotColumn{
+				FieldOrdinal:    c.FieldOrdinal,
+				Name:            c.Name,
+				Comment:         nullStringToPtr(c.Comment),
+				DataType:        c.DataType,
+				DDLType:         c.DDLType,
+				NotNull:         c.NotNull,
+				IsPrimaryKey:    c.IsPrimaryKey,
+				IsForeignKey:    c.IsForeignKey,
+				IsUniqueKey:     c.IsUniqueKey,
+				Default:         c.Default,
+				VolatileDefault: c.VolatileDefault,
+				Identity:        c.Identity,
+				Generated:       c.Generated,
+			}
+			if c.TypeDef != nil {
+				sc.TypeSchema = c.TypeDef.Schema
+				sc.TypeName = c.TypeDef.Name
+			}
+			st.Columns = append(st.Columns, sc)
+		}
+		for _, c := range tbl.Constraints {
+			st.Constraints = append(st.Constraints, &SnapshotConstraint{
+				Name:       c.Name,
+				Type:       string(c.Type),
+				Columns:    c.ColumnNames,
+				Definition: c.Definition,
+			})
+		}
+		for _, idx := range tbl.Indexes {
+			st.Indexes = append(st.Indexes, &SnapshotIndex{
+				Name:      idx.Name,
+				Columns:   idx.Columns,
+				IsUnique:  idx.IsUnique,
+				IsPrimary: idx.IsPrimary,
+				Predicate: idx.Predicate,
+				Method:    idx.Method,
+			})
+		}
+		for _, fk := range tbl.ForeingKeys {
+			sfk := &SnapshotForeignKey{
+				ConstraintName: fk.ConstraintName,
+				SourceTable:    fk.SourceTableName,
+				TargetSchema:   fk.TargetSchema,
+				TargetTable:    fk.TargetTableName,
+				OnDelete:       snapshotAction(fk.OnDelete),
+				OnUpdate:       snapshotAction(fk.OnUpdate),
+				Deferrable:     fk.IsDeferrable,
+				Deferred:       fk.IsInitiallyDeferred,
+				NotValid:       fk.NotValid,
+				Columns:        []*SnapshotForeignKeyColumn{},
+			}
+			for _, c := range fk.Columns {
+				sfk.Columns = append(sfk.Columns, &SnapshotForeignKeyColumn{
+					SourceColumn:          c.SourceColName,
+					IsSourceColPrimaryKey: c.IsSourceColPrimaryKey,
+					TargetColumn:          c.TargetColName,
+					IsTargetColPrimaryKey: c.IsTargetColPrimaryKey,
+				})
+			}
+			st.ForeignKeys = append(st.ForeignKeys, sfk)
+		}
+		st.PartitionKey = tbl.PartitionKey
+		st.PartitionBound = tbl.PartitionBound
+		st.PartitionParentSchema = tbl.PartitionParentSchema
+		st.PartitionParentTable = tbl.PartitionParentName
+		for _, d := range tbl.Dependencies {
+			if !included[d.TargetTable] {
+				continue
+			}
+			st.Dependencies = append(st.Dependencies, &SnapshotDependency{
+				TargetSchema: d.TargetSchema,
+				TargetTable:  d.TargetTableName,
+			})
+		}
+		s.Tables = append(s.Tables, st)
+	}
+	for _, t := range TableTypeDefs(tbls) {
+		s.Types = append(s.Types, &SnapshotType{
+			Schema:   t.Schema,
+			Name:     t.Name,
+			Kind:     string(t.Kind),
+			Comment:  nullStringToPtr(t.Comment),
+			Labels:   t.Labels,
+			BaseType: t.BaseType,
+			NotNull:  t.NotNull,
+			Checks:   t.Checks,
+		})
+	}
+	return s
+}
+
+// ToTables restore tables from snapshot, resolving foreign key references
+func (s *Snapshot) ToTables() ([]*Table, error) {
+	if s.Version != SnapshotVersion {
+		return nil, errors.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+	var types []*TypeDef
+	for _, st := range s.Types {
+		types = append(types, &TypeDef{
+			Schema:   st.Schema,
+			Name:     st.Name,
+			Kind:     TypeKind(st.Kind),
+			Comment:  ptrToNullString(st.Comment),
+			Labels:   st.Labels,
+			BaseType: st.BaseType,
+			NotNull:  st.NotNull,
+			Checks:   st.Checks,
+		})
+	}
+	var tbls []*Table
+	for _, st := range s.Tables {
+		t := &Table{
+			Schema:        st.Schema,
+			Name:          st.Name,
+			Kind:          TableKind(st.Kind),
+			Comment:       ptrToNullString(st.Comment),
+			AutoGenPk:     st.AutoGenPk,
+			IndexesLoaded: st.IndexesLoaded,
+
+			PartitionKey:          st.PartitionKey,
+			PartitionBound:        st.PartitionBound,
+			PartitionParentSchema: st.PartitionParentSchema,
+			PartitionParentName:   st.PartitionParentTable,
+		}
+		for _, sc := range st.Columns {
+			c := &Column{
+				FieldOrdinal:    sc.FieldOrdinal,
+				Name:            sc.Name,
+				Comment:         ptrToNullString(sc.Comment),
+				DataType:        sc.DataType,
+				DDLType:         sc.DDLType,
+				NotNull:         sc.NotNull,
+				IsPrimaryKey:    sc.IsPrimaryKey,
+				IsForeignKey:    sc.IsForeignKey,
+				IsUniqueKey:     sc.IsUniqueKey,
+				Default:         sc.Default,
+				VolatileDefault: sc.VolatileDefault,
+				Identity:        sc.Identity,
+				Generated:       sc.Generated,
+			}
+			if sc.TypeName != "" {
+				td, found := findTypeDef(types, sc.TypeSchema, sc.TypeName)
+				if !found {
+					return nil, errors.Errorf("%s.%s not found", sc.TypeSchema, sc.TypeName)
+				}
+				c.TypeSchema, c.TypeName, c.TypeDef = sc.TypeSchema, sc.TypeName, td
+			}
+			t.Columns = append(t.Columns, c)
+		}
+		for _, sc := range st.Constraints {
+			t.Constraints = append(t.Constraints, &Constraint{
+				Name:        sc.Name,
+				Type:        ConstraintType(sc.Type),
+				ColumnNames: sc.Columns,
+				Definition:  sc.Definition,
+			})
+		}
+		for _, si := range st.Indexes {
+			t.Indexes = append(t.Indexes, &Index{
+				Name:      si.Name,
+				Columns:   si.Columns,
+				IsUnique:  si.IsUnique,
+				IsPrimary: si.IsPrimary,
+				Predicate: si.Predicate,
+				Method:    si.Method,
+			})
+		}
+		tbls = append(tbls, t)
+	}
+	for i, st := range s.Tables {
+		tbl := tbls[i]
+		for _, sfk := range st.ForeignKeys {
+			if len(sfk.Columns) == 0 {
+				return nil, errors.Errorf("%s has no columns", sfk.ConstraintName)
+			}
+			fk := &ForeignKey{
+				ConstraintName:  sfk.ConstraintName,
+				SourceTableName: sfk.SourceTable,
+				SourceTable:     tbl,
+				TargetSchema:    sfk.TargetSchema,
+				TargetTableName: sfk.TargetTable,
+
+				OnDelete:            sfk.OnDelete,
+				OnUpdate:            sfk.OnUpdate,
+				IsDeferrable:        sfk.Deferrable,
+				IsInitiallyDeferred: sfk.Deferred,
+				NotValid:            sfk.NotValid,
+			}
+			for _, sc := range sfk.Columns {
+				fk.Columns = append(fk.Columns, &ForeignKeyColumn{
+					SourceColName:         sc.SourceColumn,
+					IsSourceColPrimaryKey: sc.IsSourceColPrimaryKey,
+					TargetColName:         sc.TargetColumn,
+					IsTargetColPrimaryKey: sc.IsTargetColPrimaryKey,
+				})
+			}
+			first := fk.Columns[0]
+			fk.SourceColName = first.SourceColName
+			fk.IsSourceColPrimaryKey = first.IsSourceColPrimaryKey
+			fk.TargetColName = first.TargetColName
+			fk.IsTargetColPrimaryKey = first.IsTargetColPrimaryKey
+			tbl.ForeingKeys = append(tbl.ForeingKeys, fk)
+		}
+		if err := resolveForeignKeys(tbls, tbl.ForeingKeys); err != nil {
+			return nil, errors.Wrapf(err, "failed to get fks of %s", tbl.Name)
+		}
+		// parent is not in snapshot if it was filtered out
+		if tbl.IsPartition() {
+			tbl.PartitionParent, _ = FindTableBySchemaName(tbls, tbl.PartitionParentSchema, tbl.PartitionParentName)
+		}
+		for _, sd := range st.Dependencies {
+			target, found := FindTableBySchemaName(tbls, sd.TargetSchema, sd.TargetTable)
+			if !found {
+				return nil, errors.Errorf("%s.%s not found", sd.TargetSchema, sd.TargetTable)
+			}
+			tbl.Dependencies = append(tbl.Dependencies, &ViewDependency{
+				ViewName:        tbl.Name,
+				View:            tbl,
+				TargetSchema:    sd.TargetSchema,
+				TargetTableName: sd.TargetTable,
+				TargetTable:     target,
+			})
+		}
+	}
+	return tbls, nil
+}
+
+func findTypeDef(types []*TypeDef, schema, name string) (*TypeDef, bool) {
+	for _, t := range types {
+		if t.Schema == schema && t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// TableToJSON JSON snapshot
+func TableToJSON(tbls []*Table, title string) ([]byte, error) {
+	src, err := json.MarshalIndent(NewSnapshot(tbls, title), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal json")
+	}
+	return append(src, '\n'), nil
+}
+
+// TableToYAML YAML snapshot
+func TableToYAML(tbls []*Table, title string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(NewSnapshot(tbls, title)); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal yaml")
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal yaml")
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadSnapshot load tables from JSON or YAML snapshot
+func LoadSnapshot(src []byte) ([]*Table, error) {
+	var s Snapshot
+	if b := bytes.TrimSpace(src); len(b) != 0 && b[0] == '{' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal json snapshot")
+		}
+	} else {
+		if err := yaml.Unmarshal(src, &s); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml snapshot")
+		}
+	}
+	return s.ToTables()
+}
@@ -0,0 +1,192 @@
+package main
+
+import "testing"
+
+func TestTableToJSON(t *testing.T) {
+	buf, err := TableToJSON(testTables()[:2], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "version": 2,
+  "tables": [
+    {
+      "schema": "public",
+      "name": "customer",
+      "kind": "",
+      "comment": "Customer Information",
+      "auto_gen_pk": false,
+      "columns": [
+        {
+          "field_ordinal": 1,
+          "name": "id",
+          "comment": null,
+          "data_type": "bigint",
+          "ddl_type": "bigserial",
+          "not_null": true,
+          "is_primary_key": true,
+          "is_foreign_key": false
+        },
+        {
+          "field_ordinal": 2,
+          "name": "name",
+          "comment": "Customer Name",
+          "data_type": "text",
+          "ddl_type": "text",
+          "not_null": true,
+          "is_primary_key": false,
+          "is_foreign_key": false
+        },
+        {
+          "field_ordinal": 3,
+          "name": "registered_at",
+          "comment": null,
+          "data_type": "timestamp with time zone",
+          "ddl_type": "timestamp with time zone",
+          "not_null": true,
+          "is_primary_key": false,
+          "is_foreign_key": false
+        }
+      ],
+      "foreign_keys": []
+    },
+    {
+      "schema": "public",
+      "name": "customer_order",
+      "kind": "",
+      "comment": null,
+      "auto_gen_pk": false,
+      "columns": [
+        {
+          "field_ordinal": 1,
+          "name": "id",
+          "comment": null,
+          "data_type": "bigint",
+          "ddl_type": "bigserial",
+          "not_null": true,
+          "is_primary_key": true,
+          "is_foreign_key": false
+        },
+        {
+          "field_ordinal": 2,
+          "name": "customer_id",
+          "comment": null,
+          "data_type": "bigint",
+          "ddl_type": "bigint",
+          "not_null": true,
+          "is_primary_key": false,
+          "is_foreign_key": true
+        }
+      ],
+      "foreign_keys": [
+        {
+          "constraint_name": "customer_order_customer_id_fkey",
+          "source_table": "customer_order",
+          "target_schema": "public",
+          "target_table": "customer",
+          "columns": [
+            {
+              "source_column": "customer_id",
+              "is_source_column_primary_key": false,
+              "target_column": "id",
+              "is_target_column_primary_key": false
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	for _, f := range []func([]*Table, string) ([]byte, error){TableToJSON, TableToYAML} {
+		buf, err := f(testTables(), "title")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tbls, err := LoadSnapshot(buf)
+		if err != nil {
+			t.Fatalf("%s\n%s", err, buf)
+		}
+		if len(tbls) != 4 {
+			t.Fatalf("want %d got %d", 4, len(tbls))
+		}
+		if !tbls[0].Comment.Valid || tbls[0].Comment.String != "Customer Information" {
+			t.Errorf("unexpected comment %+v", tbls[0].Comment)
+		}
+		if tbls[1].Comment.Valid {
+			t.Errorf("unexpected comment %+v", tbls[1].Comment)
+		}
+		fk := tbls[2].ForeingKeys[0]
+		if fk.SourceTable != tbls[2] || fk.TargetTable != tbls[1] {
+			t.Errorf("fk tables are not resolved: %+v", fk)
+		}
+		if fk.SourceColumn != tbls[2].Columns[1] || fk.TargetColumn != tbls[1].Columns[0] {
+			t.Errorf("fk columns are not resolved: %+v", fk)
+		}
+		fk = tbls[3].ForeingKeys[0]
+		if len(fk.Columns) != 2 || fk.Columns[1].SourceColumn != tbls[3].Columns[1] ||
+			fk.Columns[1].TargetColumn != tbls[2].Columns[1] {
+			t.Errorf("composite fk columns are not resolved: %+v", fk.Columns)
+		}
+		if !fk.IsSourceUnique() {
+			t.Errorf("want unique source columns: %s", fk.ConstraintName)
+		}
+	}
+}
+
+func TestLoadSnapshotFiltered(t *testing.T) {
+	tbls := testTables(withViews)
+	filtered := FilterTables(false, tbls, []string{"^customer$"})
+	buf, err := TableToJSON(filtered, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, buf)
+	}
+	if len(loaded) != len(filtered) {
+		t.Fatalf("want %d got %d", len(filtered), len(loaded))
+	}
+	view := loaded[len(loaded)-1]
+	if len(view.Dependencies) != 1 || view.Dependencies[0].TargetTable != loaded[0] {
+		t.Errorf("want dependency on %s only, got %+v", loaded[0].Name, view.Dependencies)
+	}
+}
+
+func TestLoadSnapshotPartition(t *testing.T) {
+	for _, filter := range []string{"^events", "^events_"} {
+		tbls := FilterTables(true, testTables(withPartitions), []string{filter})
+		buf, err := TableToJSON(tbls, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadSnapshot(buf)
+		if err != nil {
+			t.Fatalf("%s\n%s", err, buf)
+		}
+		part := loaded[len(loaded)-1]
+		if !part.IsPartition() {
+			t.Errorf("want partition: %+v", part)
+		}
+		var parent *Table
+		if len(loaded) == 3 {
+			parent = loaded[0]
+		}
+		if part.PartitionParent != parent {
+			t.Errorf("want parent %+v got %+v", parent, part.PartitionParent)
+		}
+	}
+}
+
+func TestLoadSnapshotVersion(t *testing.T) {
+	if _, err := LoadSnapshot([]byte(`{"version": 0, "tables": []}`)); err == nil {
+		t.Error("want error got nil")
+	}
+}
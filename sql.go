@@ -1,7 +1,42 @@
 package main
 
 const columDefSQL = `
+SELECT` + columnDefSelectList + `
+FROM pg_attribute a
+JOIN ONLY pg_class c ON c.oid = a.attrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid
+AND a.attnum = ANY(ct.conkey) AND ct.contype = 'p'
+LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+LEFT JOIN pg_description pd ON pd.objoid = a.attrelid AND pd.objsubid = a.attnum
+WHERE a.attisdropped = false
+AND n.nspname = $1
+AND c.relname = $2
+AND a.attnum > 0
+ORDER BY a.attnum
+`
+
+// schemaColumDefSQL loads columns of all relations of kinds in $2 in schema
+// at once. Partitions are included only if $3 is true.
+const schemaColumDefSQL = `
 SELECT
+    c.relname AS table_name,` + columnDefSelectList + `
+FROM pg_attribute a
+JOIN ONLY pg_class c ON c.oid = a.attrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid
+AND a.attnum = ANY(ct.conkey) AND ct.contype = 'p'
+LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+LEFT JOIN pg_description pd ON pd.objoid = a.attrelid AND pd.objsubid = a.attnum
+WHERE a.attisdropped = false
+AND n.nspname = $1
+AND c.relkind::text = ANY($2::text[])
+AND ($3 OR NOT COALESCE((row_to_json(c)->>'relispartition')::boolean, false))
+AND a.attnum > 0
+ORDER BY c.relname, a.attnum
+`
+
+const columnDefSelectList = `
     a.attnum AS field_ordinal,
     a.attname AS column_name,
     pd.description AS description,
@@ -24,20 +59,30 @@ SELECT
             WHEN 'int2'::regtype THEN 'smallserial'
          END
     ELSE format_type(a.atttypid, a.atttypmod)
-    END AS data_type
-FROM pg_attribute a
-JOIN ONLY pg_class c ON c.oid = a.attrelid
-JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
-LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid
-AND a.attnum = ANY(ct.conkey) AND ct.contype IN ('p', 'u')
-LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
-LEFT JOIN pg_description pd ON pd.objoid = a.attrelid AND pd.objsubid = a.attnum
-WHERE a.attisdropped = false
-AND n.nspname = $1
-AND c.relname = $2
-AND a.attnum > 0
-ORDER BY a.attnum
-`
+    END AS data_type,
+    CASE WHEN row_to_json(a)->>'attgenerated' = 's' THEN ''
+      ELSE COALESCE(pg_get_expr(ad.adbin, ad.adrelid), '')
+    END AS default_expr,
+    CASE row_to_json(a)->>'attidentity'
+      WHEN 'a' THEN 'ALWAYS'
+      WHEN 'd' THEN 'BY DEFAULT'
+      ELSE ''
+    END AS identity,
+    CASE WHEN row_to_json(a)->>'attgenerated' = 's' THEN pg_get_expr(ad.adbin, ad.adrelid)
+      ELSE ''
+    END AS generation_expr,
+    COALESCE(row_to_json(a)->>'attgenerated', '') <> 's' AND EXISTS (
+      SELECT 1
+      FROM regexp_matches(pg_get_expr(ad.adbin, ad.adrelid), '([A-Za-z_][A-Za-z0-9_]*)\(', 'g') AS f(name)
+      JOIN pg_proc p ON p.proname = f.name[1]
+      WHERE p.provolatile = 'v'
+    ) AS volatile_default,
+    (SELECT tn.nspname FROM pg_type ty JOIN pg_namespace tn ON tn.oid = ty.typnamespace
+      WHERE ty.oid = COALESCE((SELECT el.typelem FROM pg_type el WHERE el.oid = a.atttypid AND el.typcategory = 'A'), a.atttypid)
+    ) AS type_schema,
+    (SELECT ty.typname FROM pg_type ty
+      WHERE ty.oid = COALESCE((SELECT el.typelem FROM pg_type el WHERE el.oid = a.atttypid AND el.typcategory = 'A'), a.atttypid)
+    ) AS type_name`
 
 const tableDefSQL = `
 SELECT
@@ -52,27 +97,178 @@ AND c.relkind in ('r','p') AND NOT COALESCE((row_to_json(c)->>'relispartition'):
 ORDER BY c.relname
 `
 
+// constraintDefSQL loads primary key, unique, check and exclusion constraints
+// of all tables in schema
+const constraintDefSQL = `
+SELECT
+  cl.relname AS table_name,
+  con.conname AS constraint_name,
+  con.contype AS constraint_type,
+  ARRAY(
+    SELECT a.attname::text
+    FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+    JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+    ORDER BY k.ord
+  ) AS column_names,
+  pg_get_constraintdef(con.oid) AS definition
+FROM pg_constraint con
+JOIN pg_class cl ON cl.oid = con.conrelid
+JOIN ONLY pg_namespace n ON n.oid = cl.relnamespace
+WHERE n.nspname = $1
+AND con.contype IN ('p','u','c','x')
+ORDER BY
+  cl.relname,
+  CASE con.contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'x' THEN 2 ELSE 3 END,
+  con.conname
+`
+
+const viewDefSQL = `
+SELECT
+  c.relname AS view_name,
+  pd.description AS description,
+  c.relkind = 'm' AS is_materialized
+FROM pg_class c
+JOIN ONLY pg_namespace n
+ON n.oid = c.relnamespace
+LEFT JOIN pg_description pd ON pd.objoid = c.oid AND pd.objsubid = 0
+WHERE n.nspname = $1
+AND c.relkind in ('v','m')
+ORDER BY c.relname
+`
+
+// viewDependencySQL loads tables and views read by views in schema
+const viewDependencySQL = `
+SELECT DISTINCT
+  v.relname AS view_name,
+  tn.nspname AS table_schema,
+  t.relname AS table_name
+FROM pg_rewrite r
+JOIN pg_class v ON v.oid = r.ev_class
+JOIN pg_namespace vn ON vn.oid = v.relnamespace
+JOIN pg_depend d
+ON d.classid = 'pg_rewrite'::regclass AND d.objid = r.oid
+AND d.refclassid = 'pg_class'::regclass
+JOIN pg_class t ON t.oid = d.refobjid
+JOIN pg_namespace tn ON tn.oid = t.relnamespace
+WHERE vn.nspname = $1
+AND v.relkind in ('v','m')
+AND t.oid <> v.oid
+AND t.relkind in ('r','p','v','m')
+ORDER BY v.relname, tn.nspname, t.relname
+`
+
+// partitionDefSQL loads partitions in schema with their parents
+const partitionDefSQL = `
+SELECT
+  c.relname AS partition_name,
+  pd.description AS description,
+  pn.nspname AS parent_schema,
+  p.relname AS parent_name,
+  pg_get_expr(c.relpartbound, c.oid) AS partition_bound
+FROM pg_class c
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_inherits i ON i.inhrelid = c.oid
+JOIN pg_class p ON p.oid = i.inhparent
+JOIN pg_namespace pn ON pn.oid = p.relnamespace
+LEFT JOIN pg_description pd ON pd.objoid = c.oid AND pd.objsubid = 0
+WHERE n.nspname = $1
+AND c.relkind in ('r','p')
+AND c.relispartition
+ORDER BY p.relname, c.relname
+`
+
+// partitionKeySQL loads partition keys of partitioned tables in schema
+const partitionKeySQL = `
+SELECT
+  c.relname AS table_name,
+  pg_get_partkeydef(c.oid) AS partition_key
+FROM pg_class c
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1
+AND c.relkind = 'p'
+ORDER BY c.relname
+`
+
+const enumDefSQL = `
+SELECT
+  t.typname AS type_name,
+  obj_description(t.oid, 'pg_type') AS description,
+  e.enumlabel AS label
+FROM pg_type t
+JOIN ONLY pg_namespace n ON n.oid = t.typnamespace
+JOIN pg_enum e ON e.enumtypid = t.oid
+WHERE n.nspname = $1
+ORDER BY t.typname, e.enumsortorder
+`
+
+const domainDefSQL = `
+SELECT
+  t.typname AS type_name,
+  obj_description(t.oid, 'pg_type') AS description,
+  format_type(t.typbasetype, t.typtypmod) AS base_type,
+  t.typnotnull AS not_null,
+  pg_get_constraintdef(c.oid) AS check_def
+FROM pg_type t
+JOIN ONLY pg_namespace n ON n.oid = t.typnamespace
+LEFT JOIN pg_constraint c ON c.contypid = t.oid AND c.contype = 'c'
+WHERE n.nspname = $1
+AND t.typtype = 'd'
+ORDER BY t.typname, c.conname
+`
+
+const schemaListSQL = `
+SELECT n.nspname
+FROM pg_namespace n
+WHERE n.nspname NOT LIKE 'pg\_%'
+AND n.nspname <> 'information_schema'
+ORDER BY n.nspname
+`
+
+// fkDefSQL loads fks of table ordered by constraint name. Columns of
+// composite fks are ordered by their position in the constraint.
 const fkDefSQL = `
 select
   att2.attname as "child_column"
   , cl.relname as "parent_table"
   , att.attname as "parent_column"
   , con.conname
-  , case 
-      when pi.indisprimary is null then false
-      else pi.indisprimary
-    end as "is_parent_pk"
-  , case 
-      when ci.indisprimary is null then false
-      else ci.indisprimary
-    end as "is_child_pk"
+  , exists (
+      select 1 from pg_index pi
+      where pi.indrelid = att.attrelid and pi.indisprimary
+      and att.attnum = any(pi.indkey)
+    ) as "is_parent_pk"
+  , exists (
+      select 1 from pg_index ci
+      where ci.indrelid = att2.attrelid and ci.indisprimary
+      and att2.attnum = any(ci.indkey)
+    ) as "is_child_pk"
+  , tns.nspname as "parent_schema"
+  , case con.confdeltype
+      when 'c' then 'CASCADE' when 'n' then 'SET NULL'
+      when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT'
+      else 'NO ACTION'
+    end as "on_delete"
+  , case con.confupdtype
+      when 'c' then 'CASCADE' when 'n' then 'SET NULL'
+      when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT'
+      else 'NO ACTION'
+    end as "on_update"
+  , con.condeferrable
+  , con.condeferred
+  , not con.convalidated as "not_valid"
 from (
   select 
     unnest(con1.conkey) as "parent"
     , unnest(con1.confkey) as "child"
+    , generate_subscripts(con1.conkey, 1) as "position"
     , con1.confrelid
     , con1.conrelid
     , con1.conname
+    , con1.confdeltype
+    , con1.confupdtype
+    , con1.condeferrable
+    , con1.condeferred
+    , con1.convalidated
   from pg_class cl
   join pg_namespace ns on cl.relnamespace = ns.oid
   join pg_constraint con1 on con1.conrelid = cl.oid
@@ -83,13 +279,99 @@ from (
 ) con
 join pg_attribute att
 on att.attrelid = con.confrelid and att.attnum = con.child
-left outer join pg_index pi
-on att.attrelid = pi.indrelid and att.attnum = any(pi.indkey)
 join pg_class cl
 on cl.oid = con.confrelid
+join pg_namespace tns
+on tns.oid = cl.relnamespace
 join pg_attribute att2
 on att2.attrelid = con.conrelid and att2.attnum = con.parent
-left outer join pg_index ci
-on att2.attrelid = ci.indrelid and att2.attnum = any(ci.indkey)
-order by con.conname
+order by con.conname, con.position
+`
+
+// schemaFkDefSQL loads fks of all tables in schema at once, ordered by table
+// and then like fkDefSQL
+const schemaFkDefSQL = `
+select
+  con.source_table
+  , att2.attname as "child_column"
+  , cl.relname as "parent_table"
+  , att.attname as "parent_column"
+  , con.conname
+  , exists (
+      select 1 from pg_index pi
+      where pi.indrelid = att.attrelid and pi.indisprimary
+      and att.attnum = any(pi.indkey)
+    ) as "is_parent_pk"
+  , exists (
+      select 1 from pg_index ci
+      where ci.indrelid = att2.attrelid and ci.indisprimary
+      and att2.attnum = any(ci.indkey)
+    ) as "is_child_pk"
+  , tns.nspname as "parent_schema"
+  , case con.confdeltype
+      when 'c' then 'CASCADE' when 'n' then 'SET NULL'
+      when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT'
+      else 'NO ACTION'
+    end as "on_delete"
+  , case con.confupdtype
+      when 'c' then 'CASCADE' when 'n' then 'SET NULL'
+      when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT'
+      else 'NO ACTION'
+    end as "on_update"
+  , con.condeferrable
+  , con.condeferred
+  , not con.convalidated as "not_valid"
+from (
+  select 
+    unnest(con1.conkey) as "parent"
+    , unnest(con1.confkey) as "child"
+    , generate_subscripts(con1.conkey, 1) as "position"
+    , con1.confrelid
+    , con1.conrelid
+    , con1.conname
+    , con1.confdeltype
+    , con1.confupdtype
+    , con1.condeferrable
+    , con1.condeferred
+    , con1.convalidated
+    , cl.relname as "source_table"
+  from pg_class cl
+  join pg_namespace ns on cl.relnamespace = ns.oid
+  join pg_constraint con1 on con1.conrelid = cl.oid
+  where ns.nspname = $1
+  and con1.contype = 'f'
+  and (coalesce((row_to_json(con1)->>'conparentid'),'0')::oid) = 0
+) con
+join pg_attribute att
+on att.attrelid = con.confrelid and att.attnum = con.child
+join pg_class cl
+on cl.oid = con.confrelid
+join pg_namespace tns
+on tns.oid = cl.relnamespace
+join pg_attribute att2
+on att2.attrelid = con.conrelid and att2.attnum = con.parent
+order by con.source_table, con.conname, con.position
+`
+
+// indexDefSQL loads indexes of tables in schema
+const indexDefSQL = `
+SELECT
+  t.relname AS table_name,
+  i.relname AS index_name,
+  ARRAY(
+    SELECT pg_get_indexdef(ix.indexrelid, k, true)
+    FROM generate_series(1, COALESCE((row_to_json(ix)->>'indnkeyatts')::int, ix.indnatts)) AS k
+    ORDER BY k
+  ) AS columns,
+  ix.indisunique AS is_unique,
+  ix.indisprimary AS is_primary,
+  coalesce(pg_get_expr(ix.indpred, ix.indrelid, true), '') AS predicate,
+  am.amname AS method
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN ONLY pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_am am ON am.oid = i.relam
+WHERE n.nspname = $1
+ORDER BY t.relname, ix.indisprimary DESC, i.relname
 `
@@ -1,25 +1,369 @@
 package main
 
 const entryTmpl = `
-entity "**{{ .Name }}**" {
+entity "**{{ entityName .Schema .Name }}**"{{ stereotype . }}{{ tableColor . }} {
 {{- if .Comment.Valid }}
   {{ .Comment.String }}
   ..
 {{- end }}
+{{- if .PartitionKey }}
+  //PARTITION BY {{ .PartitionKey }}//
+  ..
+{{- end }}
 {{- range .Columns }}
   {{- if .IsPrimaryKey }}
-  + ""{{ .Name }}"": //{{ .DDLType }} [PK]{{if .IsForeignKey }}[FK]{{end}}{{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
+  + {{ changeStart .Change }}""{{ .Name }}"": //{{ .DDLType }}{{with .DefaultClause}} {{ . }}{{end}} [PK]{{if .IsForeignKey }}[FK]{{end}}{{if .IsUniqueKey }}[UK]{{end}}{{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//{{ changeEnd .Change }}
   {{- end }}
 {{- end }}
   --
 {{- range .Columns }}
   {{- if not .IsPrimaryKey }}
-  {{if .NotNull}}*{{end}}""{{ .Name }}"": //{{ .DDLType }} {{if .IsForeignKey}}[FK]{{end}} {{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//
+  {{if .NotNull}}*{{end}}{{ changeStart .Change }}""{{ .Name }}"": //{{ .DDLType }}{{with .DefaultClause}} {{ . }}{{end}} {{if .IsForeignKey}}[FK]{{end}}{{if .IsUniqueKey}}[UK]{{end}} {{- if .Comment.Valid }} : {{ .Comment.String }}{{- end }}//{{ changeEnd .Change }}
   {{- end }}
 {{- end }}
+{{- with .SecondaryConstraints }}
+  ..
+{{- range . }}
+  ""{{ .Name }}"": //{{ .Definition }}//
+{{- end }}
+{{- end }}
+{{- with .Indexes }}
+  == indexes ==
+{{- range . }}
+  ""{{ .Name }}"": //{{if .IsPrimary}}[PK] {{else if .IsUnique}}[UNIQUE] {{end}}{{ .Method }} ({{ join .Columns ", " }}){{with .Predicate}} WHERE {{ . }}{{end}}//
+{{- end }}
+{{- end }}
+}
+`
+
+const boundaryTmpl = `
+entity "**{{ entityName .Schema .Name }}**"{{ stereotype . }} #EEEEEE {
 }
 `
 
 const relationTmpl = `
-"**{{ .SourceTableName }}**" {{if .IsOneToOne}} ||-|| {{else}}  }-- {{end}} "**{{ .TargetTableName }}**"
+"**{{ entityName .SourceTable.Schema .SourceTableName }}**" {{ relationArrow . }} "**{{ entityName .TargetSchema .TargetTableName }}**"{{ with relationLabel . }} : {{ . }}{{ end }}
+`
+
+const partitionTmpl = `
+entity "**{{ entityName .Schema .Name }}**"{{ stereotype . }} {
+{{- if .Comment.Valid }}
+  {{ .Comment.String }}
+  ..
+{{- end }}
+  //{{ .PartitionBound }}//
+{{- if .PartitionKey }}
+  //PARTITION BY {{ .PartitionKey }}//
+{{- end }}
+}
+`
+
+const partitionRelationTmpl = `
+"**{{ entityName .Schema .Name }}**" --|> "**{{ entityName .PartitionParentSchema .PartitionParentName }}**"
+`
+
+const typeDefTmpl = `
+enum "**{{ entityName .Schema .Name }}**"{{ typeStereotype . }} {
+{{- if .Comment.Valid }}
+  {{ .Comment.String }}
+  ..
+{{- end }}
+{{- range .Labels }}
+  {{ . }}
+{{- end }}
+{{- if .BaseType }}
+  //{{ .BaseType }}{{ if .NotNull }} NOT NULL{{ end }}//
+{{- end }}
+{{- range .Checks }}
+  {{ . }}
+{{- end }}
+}
+`
+
+const typeDefRelationTmpl = `
+"**{{ entityName .Table.Schema .Table.Name }}**" ..> "**{{ entityName .TypeDef.Schema .TypeDef.Name }}**" : {{ .Column.Name }}
+`
+
+const dependencyTmpl = `
+"**{{ entityName .View.Schema .ViewName }}**" ..> "**{{ entityName .TargetSchema .TargetTableName }}**"
+`
+
+const mermaidEntryTmpl = `
+{{- if .Comment.Valid }}
+  %% {{ mermaidComment .Comment.String }}
+{{- end }}
+  {{ mermaidName (entityName .Schema .Name) }} {
+{{- range .Columns }}
+    {{ mermaidName .DDLType }} {{ mermaidName .Name }}{{ mermaidKeys . }}{{- if .Comment.Valid }} "{{ mermaidComment .Comment.String }}"{{- end }}
+{{- end }}
+  }
+`
+
+const mermaidBoundaryTmpl = `
+  {{ mermaidName (entityName .Schema .Name) }} {
+  }
+`
+
+const mermaidRelationTmpl = `
+  {{ mermaidName (entityName .SourceTable.Schema .SourceTableName) }} {{ mermaidArrow . }} {{ mermaidName (entityName .TargetSchema .TargetTableName) }} : "{{ mermaidComment (relationLabel .) }}"
+`
+
+const dotEntryTmpl = `
+  {{ dotID (entityName .Schema .Name) }} [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="lightgrey"><b>{{ html (entityName .Schema .Name) }}</b></td></tr>
+{{- if .Comment.Valid }}
+      <tr><td><i>{{ html .Comment.String }}</i></td></tr>
+{{- end }}
+{{- range .Columns }}
+      <tr><td port={{ dotID .Name }} align="left">{{ if .IsPrimaryKey }}<u>{{ html .Name }}</u>{{ else }}{{ html .Name }}{{ end }}{{ if .NotNull }}*{{ end }} : {{ html .DDLType }}{{ if .IsPrimaryKey }} [PK]{{ end }}{{ if .IsForeignKey }} [FK]{{ end }}{{- if .Comment.Valid }} : {{ html .Comment.String }}{{- end }}</td></tr>
+{{- end }}
+    </table>>];
+`
+
+const dotBoundaryTmpl = `
+  {{ dotID (entityName .Schema .Name) }} [label=<
+    <table border="0" cellborder="1" cellspacing="0" cellpadding="4">
+      <tr><td bgcolor="#EEEEEE"><font color="grey">{{ html (entityName .Schema .Name) }}</font></td></tr>
+    </table>>];
+`
+
+const dotRelationTmpl = `
+  {{ dotID (entityName .SourceTable.Schema .SourceTableName) }}{{ dotPort .SourceTable .SourceColName }} -> {{ dotID (entityName .TargetSchema .TargetTableName) }}{{ dotPort .TargetTable .TargetColName }} [arrowtail={{ dotArrowTail . }}, arrowhead={{ dotArrowHead . }}{{ with relationLabel . }}, label={{ dotID . }}{{ end }}];
+`
+
+const dbmlEntryTmpl = `
+Table {{ dbmlTable .Schema .Name }} {
+{{- range .Columns }}
+  {{ dbmlID .Name }} {{ dbmlID .DDLType }}{{ dbmlSettings . $.IsCompositePK }}
+{{- end }}
+{{- if .IsCompositePK }}
+
+  indexes {
+    {{ dbmlPrimaryKey . }} [pk]
+  }
+{{- end }}
+{{- if .Comment.Valid }}
+
+  Note: {{ dbmlString .Comment.String }}
+{{- end }}
+}
+`
+
+const dbmlRelationTmpl = `
+Ref: {{ dbmlTable .SourceTable.Schema .SourceTableName }}.{{ dbmlColumns .SourceColNames }} {{if .IsSourceUnique}}-{{else}}>{{end}} {{ dbmlTable .TargetSchema .TargetTableName }}.{{ dbmlColumns .TargetColNames }}
+`
+
+const markdownEntryTmpl = `
+## {{ entityName .Table.Schema .Table.Name }}
+{{- if .Table.Comment.Valid }}
+
+{{ markdownCell .Table.Comment.String }}
+{{- end }}
+
+| Column | Type | Nullable | Key | Comment |
+|--------|------|----------|-----|---------|
+{{- range .Table.Columns }}
+| {{ markdownCell .Name }} | {{ markdownCell .DDLType }} | {{ if .NotNull }}NO{{ else }}YES{{ end }} | {{ markdownKeys . }} | {{ if .Comment.Valid }}{{ markdownCell .Comment.String }}{{ end }} |
+{{- end }}
+{{- if .Table.ForeingKeys }}
+
+### References
+{{ range .Table.ForeingKeys }}
+- {{ markdownColumns .SourceColNames }} → [{{ entityName .TargetSchema .TargetTableName }}](#{{ markdownAnchor (entityName .TargetSchema .TargetTableName) }}).{{ markdownColumns .TargetColNames }}
+{{- end }}
+{{- end }}
+{{- if .ReferencedBy }}
+
+### Referenced by
+{{ range .ReferencedBy }}
+- [{{ entityName .SourceTable.Schema .SourceTableName }}](#{{ markdownAnchor (entityName .SourceTable.Schema .SourceTableName) }}).{{ markdownColumns .SourceColNames }} → {{ markdownColumns .TargetColNames }}
+{{- end }}
+{{- end }}
+`
+
+const htmlTmpl = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ if .Title }}{{ .Title }}{{ else }}planter{{ end }}</title>
+<style>
+body { margin: 0; font-family: sans-serif; font-size: 14px; display: flex; height: 100vh; }
+#sidebar { width: 280px; border-right: 1px solid #ccc; display: flex; flex-direction: column; }
+#search { margin: 8px; padding: 4px; }
+#tables { list-style: none; margin: 0; padding: 0; overflow-y: auto; flex: 1; }
+#tables li { padding: 4px 12px; cursor: pointer; }
+#tables li:hover { background: #eef; }
+#tables li.selected { background: #ccf; font-weight: bold; }
+#tables li.neighbour { background: #eef; }
+#main { flex: 1; padding: 16px; overflow-y: auto; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+a { color: #33c; cursor: pointer; }
+.comment { color: #666; }
+</style>
+</head>
+<body>
+<div id="sidebar">
+  <input id="search" type="search" placeholder="search tables and columns">
+  <ul id="tables"></ul>
+</div>
+<div id="main"><p class="comment">select a table</p></div>
+<script>
+var model = {{ . }};
+
+function el(tag, text) {
+  var e = document.createElement(tag);
+  if (text !== undefined && text !== null) {
+    e.textContent = text;
+  }
+  return e;
+}
+
+// tables are identified by schema qualified names, and shown with them if
+// tables of multiple schemas are embedded
+var schemas = {};
+model.tables.forEach(function (t) {
+  schemas[t.schema] = true;
+  t.foreign_keys.forEach(function (fk) { fk.source_schema = t.schema; });
+});
+var multiSchema = Object.keys(schemas).length > 1;
+
+function tableKey(schema, name) {
+  return schema + "." + name;
+}
+
+function displayName(schema, name) {
+  return multiSchema ? tableKey(schema, name) : name;
+}
+
+function findTable(key) {
+  for (var i = 0; i < model.tables.length; i++) {
+    if (tableKey(model.tables[i].schema, model.tables[i].name) === key) {
+      return model.tables[i];
+    }
+  }
+  return null;
+}
+
+function referencedBy(t) {
+  var fks = [];
+  model.tables.forEach(function (s) {
+    s.foreign_keys.forEach(function (fk) {
+      if (fk.target_schema === t.schema && fk.target_table === t.name) {
+        fks.push(fk);
+      }
+    });
+  });
+  return fks;
+}
+
+function tableLink(schema, name) {
+  var a = el("a", displayName(schema, name));
+  a.onclick = function () { focusTable(tableKey(schema, name)); };
+  return a;
+}
+
+function renderList(selected) {
+  var q = document.getElementById("search").value.toLowerCase();
+  var neighbours = {};
+  if (selected) {
+    selected.foreign_keys.forEach(function (fk) { neighbours[tableKey(fk.target_schema, fk.target_table)] = true; });
+    referencedBy(selected).forEach(function (fk) { neighbours[tableKey(fk.source_schema, fk.source_table)] = true; });
+  }
+  var ul = document.getElementById("tables");
+  ul.innerHTML = "";
+  model.tables.forEach(function (t) {
+    var hit = t.name.toLowerCase().indexOf(q) >= 0 || t.columns.some(function (c) {
+      return c.name.toLowerCase().indexOf(q) >= 0;
+    });
+    if (!hit) {
+      return;
+    }
+    var key = tableKey(t.schema, t.name);
+    var li = el("li", displayName(t.schema, t.name));
+    if (selected && t === selected) {
+      li.className = "selected";
+    } else if (neighbours[key]) {
+      li.className = "neighbour";
+    }
+    li.onclick = function () { focusTable(key); };
+    ul.appendChild(li);
+  });
+}
+
+function columnNames(fk, key) {
+  var names = fk.columns.map(function (c) { return c[key]; });
+  return names.length === 1 ? names[0] : "(" + names.join(", ") + ")";
+}
+
+function renderFks(main, title, fks, outgoing) {
+  if (fks.length === 0) {
+    return;
+  }
+  main.appendChild(el("h3", title));
+  var ul = el("ul");
+  fks.forEach(function (fk) {
+    var li = el("li");
+    if (outgoing) {
+      li.appendChild(el("code", columnNames(fk, "source_column")));
+      li.appendChild(document.createTextNode(" → "));
+      li.appendChild(tableLink(fk.target_schema, fk.target_table));
+      li.appendChild(document.createTextNode("." + columnNames(fk, "target_column")));
+    } else {
+      li.appendChild(tableLink(fk.source_schema, fk.source_table));
+      li.appendChild(document.createTextNode("." + columnNames(fk, "source_column") + " → "));
+      li.appendChild(el("code", columnNames(fk, "target_column")));
+    }
+    ul.appendChild(li);
+  });
+  main.appendChild(ul);
+}
+
+function focusTable(key) {
+  var t = findTable(key);
+  if (!t) {
+    return;
+  }
+  location.hash = encodeURIComponent(key);
+  renderList(t);
+  var main = document.getElementById("main");
+  main.innerHTML = "";
+  main.appendChild(el("h2", t.schema + "." + t.name));
+  if (t.comment !== null) {
+    main.appendChild(el("p", t.comment)).className = "comment";
+  }
+  var tbl = el("table");
+  var head = el("tr");
+  ["#", "Column", "Type", "Nullable", "Key", "Comment"].forEach(function (h) {
+    head.appendChild(el("th", h));
+  });
+  tbl.appendChild(head);
+  t.columns.forEach(function (c) {
+    var keys = [];
+    if (c.is_primary_key) { keys.push("PK"); }
+    if (c.is_foreign_key) { keys.push("FK"); }
+    var tr = el("tr");
+    [c.field_ordinal, c.name, c.ddl_type, c.not_null ? "NO" : "YES", keys.join(", "), c.comment].forEach(function (v) {
+      tr.appendChild(el("td", v));
+    });
+    tbl.appendChild(tr);
+  });
+  main.appendChild(tbl);
+  renderFks(main, "References", t.foreign_keys, true);
+  renderFks(main, "Referenced by", referencedBy(t), false);
+}
+
+document.getElementById("search").oninput = function () {
+  renderList(findTable(decodeURIComponent(location.hash.slice(1))));
+};
+if (location.hash) {
+  focusTable(decodeURIComponent(location.hash.slice(1)));
+} else {
+  renderList(null);
+}
+</script>
+</body>
+</html>
 `
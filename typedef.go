@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// TypeKind kind of user defined type
+type TypeKind string
+
+// user defined type kinds
+const (
+	TypeKindEnum   TypeKind = "enum"
+	TypeKindDomain TypeKind = "domain"
+)
+
+// TypeDef enum or domain type
+type TypeDef struct {
+	Schema   string
+	Name     string
+	Kind     TypeKind
+	Comment  sql.NullString
+	Labels   []string
+	BaseType string
+	NotNull  bool
+	Checks   []string
+}
+
+// Matches returns true if column is typed with this type or an array of it
+func (t *TypeDef) Matches(c *Column) bool {
+	return c.TypeSchema == t.Schema && c.TypeName == t.Name
+}
+
+// LoadTypeDef load enum and domain types
+func LoadTypeDef(db Queryer, schemas []string, tbls []*Table) ([]*TypeDef, error) {
+	return LoadTypeDefContext(context.Background(), withContext(db), schemas, tbls)
+}
+
+// LoadTypeDefContext load enum and domain types with context, and link
+// columns of tbls to the types
+func LoadTypeDefContext(ctx context.Context, db QueryerContext, schemas []string, tbls []*Table) ([]*TypeDef, error) {
+	var types []*TypeDef
+	for _, schema := range schemas {
+		enums, err := loadEnumsContext(ctx, db, schema)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, enums...)
+		domains, err := loadDomainsContext(ctx, db, schema)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, domains...)
+	}
+	LinkTypeDefs(tbls, types)
+	return types, nil
+}
+
+// LinkTypeDefs set TypeDef of columns typed with one of types
+func LinkTypeDefs(tbls []*Table, types []*TypeDef) {
+	for _, tbl := range tbls {
+		for _, c := range tbl.Columns {
+			for _, t := range types {
+				if t.Matches(c) {
+					c.TypeDef = t
+					break
+				}
+			}
+		}
+	}
+}
+
+func loadEnumsContext(ctx context.Context, db QueryerContext, schema string) ([]*TypeDef, error) {
+	rows, err := db.QueryContext(ctx, enumDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load enum def")
+	}
+	defer rows.Close()
+	var types []*TypeDef
+	for rows.Next() {
+		var (
+			name, label string
+			comment     sql.NullString
+		)
+		if err := rows.Scan(&name, &comment, &label); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		if n := len(types); n == 0 || types[n-1].Name != name {
+			types = append(types, &TypeDef{
+				Schema:  schema,
+				Name:    name,
+				Kind:    TypeKindEnum,
+				Comment: comment,
+			})
+		}
+		t := types[len(types)-1]
+		t.Labels = append(t.Labels, label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load enum def")
+	}
+	return types, nil
+}
+
+func loadDomainsContext(ctx context.Context, db QueryerContext, schema string) ([]*TypeDef, error) {
+	rows, err := db.QueryContext(ctx, domainDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load domain def")
+	}
+	defer rows.Close()
+	var types []*TypeDef
+	for rows.Next() {
+		var (
+			d     TypeDef
+			check sql.NullString
+		)
+		if err := rows.Scan(&d.Name, &d.Comment, &d.BaseType, &d.NotNull, &check); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		if n := len(types); n == 0 || types[n-1].Name != d.Name {
+			d.Schema = schema
+			d.Kind = TypeKindDomain
+			types = append(types, &d)
+		}
+		if check.Valid {
+			t := types[len(types)-1]
+			t.Checks = append(t.Checks, check.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load domain def")
+	}
+	return types, nil
+}
+
+// TableTypeDefs types used by columns of tbls in order of appearance
+func TableTypeDefs(tbls []*Table) []*TypeDef {
+	var types []*TypeDef
+	seen := make(map[*TypeDef]bool)
+	for _, tbl := range tbls {
+		for _, c := range tbl.Columns {
+			if c.TypeDef != nil && !seen[c.TypeDef] {
+				seen[c.TypeDef] = true
+				types = append(types, c.TypeDef)
+			}
+		}
+	}
+	return types
+}
+
+// TypeDefToUMLEntry enum and domain types used by columns of tbls
+func TypeDefToUMLEntry(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("typeDef").Funcs(umlFuncMap(isMultiSchema(tbls))).Parse(typeDefTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, t := range TableTypeDefs(tbls) {
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, t); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template: %s", t.Name)
+		}
+		src = append(src, buf.Bytes()...)
+	}
+	return src, nil
+}
+
+// typeDefRelation column typed with enum or domain type
+type typeDefRelation struct {
+	Table   *Table
+	Column  *Column
+	TypeDef *TypeDef
+}
+
+// TypeDefToUMLRelation relation from tables to enum and domain types of their columns
+func TypeDefToUMLRelation(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("typeDefRelation").Funcs(umlFuncMap(isMultiSchema(tbls))).Parse(typeDefRelationTmpl)
+	if err != nil {
+		return nil, err
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		if tbl.IsPartition() {
+			continue
+		}
+		for _, c := range tbl.Columns {
+			if c.TypeDef == nil {
+				continue
+			}
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, typeDefRelation{Table: tbl, Column: c, TypeDef: c.TypeDef}); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", c.Name)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypeDefMatches(t *testing.T) {
+	td := &TypeDef{Schema: "billing", Name: "order_status"}
+	cases := []struct {
+		col      *Column
+		expected bool
+	}{
+		{col: &Column{DataType: "order_status", TypeSchema: "billing", TypeName: "order_status"}, expected: true},
+		{col: &Column{DataType: "order_status[]", TypeSchema: "billing", TypeName: "order_status"}, expected: true},
+		{col: &Column{DataType: "order_status", TypeSchema: "core", TypeName: "order_status"}, expected: false},
+		{col: &Column{DataType: "text", TypeSchema: "pg_catalog", TypeName: "text"}, expected: false},
+	}
+	for _, c := range cases {
+		if got := td.Matches(c.col); got != c.expected {
+			t.Errorf("%s.%s: want %t got %t", c.col.TypeSchema, c.col.TypeName, c.expected, got)
+		}
+	}
+}
+
+func TestTypeDefToPlantUML(t *testing.T) {
+	tbls := testTables(withTypes)
+	order := tbls[1]
+	types := TableTypeDefs(tbls)
+	if len(types) != 2 || order.Columns[2].TypeDef != types[0] || order.Columns[3].TypeDef != types[1] {
+		t.Fatalf("columns are not linked: %+v", order.Columns)
+	}
+
+	buf, err := TableToPlantUML(tbls[:2], "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**customer**" {
+  Customer Information
+  ..
+  + ""id"": //bigserial [PK]//
+  --
+  *""name"": //text  : Customer Name//
+  *""registered_at"": //timestamp with time zone //
+}
+
+entity "**customer_order**" {
+  + ""id"": //bigserial [PK]//
+  --
+  *""customer_id"": //bigint [FK]//
+  *""status"": //order_status //
+  *""total_price"": //price //
+}
+
+enum "**order_status**" {
+  ordered
+  shipped
+  delivered
+}
+
+enum "**price**" <<domain>> {
+  //numeric NOT NULL//
+  CHECK (VALUE >= 0)
+}
+
+"**customer_order**" }o--|| "**customer**"
+
+"**customer_order**" ..> "**order_status**" : status
+
+"**customer_order**" ..> "**price**" : total_price
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+
+	buf, err = TableToJSON(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td := loaded[1].Columns[2].TypeDef; !reflect.DeepEqual(td, types[0]) {
+		t.Errorf("want %+v got %+v", types[0], td)
+	}
+}
+
+func TestLoadTypeDef(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	tbls, err := LoadTableDef(conn, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := LoadTypeDef(conn, []string{"public"}, tbls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*TypeDef{
+		{
+			Schema:  "public",
+			Name:    "order_status",
+			Kind:    TypeKindEnum,
+			Comment: sql.NullString{String: "Status of order", Valid: true},
+			Labels:  []string{"ordered", "shipped", "delivered"},
+		},
+		{
+			Schema:   "public",
+			Name:     "price",
+			Kind:     TypeKindDomain,
+			BaseType: "numeric",
+			NotNull:  true,
+			Checks:   []string{"CHECK ((VALUE >= (0)::numeric))"},
+		},
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("want %+v got %+v", expected, types)
+	}
+
+	shipment, found := FindTableByName(tbls, "shipment")
+	if !found {
+		t.Fatal("shipment not found")
+	}
+	var got []string
+	for _, c := range shipment.Columns {
+		s := fmt.Sprintf("%s %s %s.%s", c.Name, c.DataType, c.TypeSchema, c.TypeName)
+		if c.TypeDef != nil {
+			s += " -> " + c.TypeDef.Name
+		}
+		got = append(got, s)
+	}
+	expectedCols := []string{
+		"id bigint pg_catalog.int8",
+		"customer_order_id bigint pg_catalog.int8",
+		"status order_status public.order_status -> order_status",
+		"status_history order_status[] public.order_status -> order_status",
+		"fee price public.price -> price",
+	}
+	if !reflect.DeepEqual(got, expectedCols) {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(expectedCols, "\n"), strings.Join(got, "\n"))
+	}
+}
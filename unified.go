@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// unifiedContext number of context lines in unified diff
+const unifiedContext = 3
+
+// lineOp line of edit script: ' ' equal, '-' deleted or '+' inserted
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// maxEditDistance edit distance beyond which diffLines gives up the shortest
+// edit script and replaces differing lines at once, so that time and memory
+// stay bounded for large unrelated files
+const maxEditDistance = 1000
+
+// splitLines lines with their newlines, so that the last line without
+// newline differs from the same line with newline
+func splitLines(src []byte) []string {
+	if len(src) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(src), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines edit script from a to b. Common leading and trailing lines are
+// skipped before searching the shortest edit script of the rest.
+func diffLines(a, b []string) []lineOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []lineOp
+	for _, l := range a[:pre] {
+		ops = append(ops, lineOp{' ', l})
+	}
+	ops = append(ops, shortestEdit(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, lineOp{' ', l})
+	}
+	return ops
+}
+
+// replaceLines edit script deleting all lines of a and inserting all of b
+func replaceLines(a, b []string) []lineOp {
+	var ops []lineOp
+	for _, l := range a {
+		ops = append(ops, lineOp{'-', l})
+	}
+	for _, l := range b {
+		ops = append(ops, lineOp{'+', l})
+	}
+	return ops
+}
+
+// shortestEdit shortest edit script from a to b by Myers' algorithm. trace
+// keeps only diagonals -d..d reached at each edit distance d.
+func shortestEdit(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceLines(a, b)
+	}
+	total := n + m
+	off := total + 1
+	v := make([]int, 2*total+3)
+	var trace [][]int
+	var d int
+loop:
+	for d = 0; d <= total; d++ {
+		if d > maxEditDistance {
+			return replaceLines(a, b)
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break loop
+			}
+		}
+		trace = append(trace, append([]int{}, v[off-d:off+d+1]...))
+	}
+
+	var ops []lineOp
+	x, y := n, m
+	for ; d > 0; d-- {
+		// x of diagonal k reached at edit distance d-1
+		prev := func(k int) int { return trace[d-1][k+d-1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			prevK = k + 1
+		}
+		prevX := prev(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, lineOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, lineOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, lineOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, lineOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunkRange range of unified diff hunk header. start is the line before
+// the hunk if it's empty.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// UnifiedDiff unified diff of a and b, empty if they are the same
+func UnifiedDiff(aName, bName string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	// line numbers of a and b before each op
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - unifiedContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*unifiedContext {
+				break
+			}
+			end = next
+		}
+		stop := end + unifiedContext
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n",
+			hunkRange(aPos[start], aPos[stop]-aPos[start]), hunkRange(bPos[start], bPos[stop]-bPos[start]))
+		for _, op := range ops[start:stop] {
+			fmt.Fprintf(buf, "%c%s", op.kind, op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	cases := []struct {
+		b        string
+		expected string
+	}{
+		{b: a, expected: ""},
+		{
+			b: "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n",
+			expected: `--- old
++++ new
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`,
+		},
+		{
+			b: "b\nc\nd\ne\nf\ng\nh\nX\ni\nj\nk\nl\nm\n",
+			expected: `--- old
++++ new
+@@ -1,4 +1,3 @@
+-a
+ b
+ c
+ d
+@@ -6,6 +5,7 @@
+ f
+ g
+ h
++X
+ i
+ j
+ k
+`,
+		},
+		{
+			b: strings.TrimSuffix(a, "\n"),
+			expected: `--- old
++++ new
+@@ -10,4 +10,4 @@
+ j
+ k
+ l
+-m
++m
+\ No newline at end of file
+`,
+		},
+		{
+			b: "",
+			expected: "--- old\n+++ new\n@@ -1,13 +0,0 @@\n-" +
+				strings.Join(strings.Split(strings.TrimSuffix(a, "\n"), "\n"), "\n-") + "\n",
+		},
+	}
+	for _, c := range cases {
+		if got := string(UnifiedDiff("old", "new", []byte(a), []byte(c.b))); got != c.expected {
+			t.Errorf("want\n%s\ngot\n%s", c.expected, got)
+		}
+	}
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+	var a, b strings.Builder
+	n := 4 * maxEditDistance
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	got := UnifiedDiff("old", "new", []byte(a.String()), []byte(b.String()))
+	if !bytes.HasPrefix(got, []byte(fmt.Sprintf("--- old\n+++ new\n@@ -1,%d +1,%d @@\n-a0\n", n, n))) {
+		t.Errorf("unexpected diff header: %s", got[:64])
+	}
+	if lines := bytes.Count(got, []byte("\n")); lines != 2*n+3 {
+		t.Errorf("want %d lines, got %d", 2*n+3, lines)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// ViewDependency table or view read by view
+type ViewDependency struct {
+	ViewName        string
+	View            *Table
+	TargetSchema    string
+	TargetTableName string
+	TargetTable     *Table
+}
+
+// LoadViewDef load Postgres view and materialized view definitions
+func LoadViewDef(db Queryer, schemas []string, tbls []*Table, cols map[string]map[string][]*Column) ([]*Table, error) {
+	return LoadViewDefContext(context.Background(), withContext(db), schemas, tbls, cols)
+}
+
+// LoadViewDefContext load Postgres view and materialized view definitions with
+// context. Columns are taken from cols loaded by LoadSchemasColumnDefContext
+// with views. Dependencies are resolved against tbls and the loaded views, and
+// dependencies on relations not loaded are ignored.
+func LoadViewDefContext(ctx context.Context, db QueryerContext, schemas []string, tbls []*Table, cols map[string]map[string][]*Column) ([]*Table, error) {
+	var views []*Table
+	for _, schema := range schemas {
+		vs, err := loadViewsContext(ctx, db, schema, cols[schema])
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, vs...)
+	}
+	all := append(append([]*Table{}, tbls...), views...)
+	for _, schema := range schemas {
+		if err := loadViewDependencyContext(ctx, db, schema, all); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to get dependencies of views in %s", schema))
+		}
+	}
+	return views, nil
+}
+
+func loadViewsContext(ctx context.Context, db QueryerContext, schema string, cols map[string][]*Column) ([]*Table, error) {
+	vDefs, err := db.QueryContext(ctx, viewDefSQL, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load view def")
+	}
+	defer vDefs.Close()
+	var views []*Table
+	for vDefs.Next() {
+		var materialized bool
+		v := &Table{Schema: schema, Kind: TableKindView}
+		if err := vDefs.Scan(&v.Name, &v.Comment, &materialized); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		if materialized {
+			v.Kind = TableKindMaterializedView
+		}
+		views = append(views, v)
+	}
+	if err := vDefs.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to load view def")
+	}
+	for _, v := range views {
+		v.Columns = cols[v.Name]
+	}
+	return views, nil
+}
+
+func loadViewDependencyContext(ctx context.Context, db QueryerContext, schema string, tbls []*Table) error {
+	deps, err := db.QueryContext(ctx, viewDependencySQL, schema)
+	if err != nil {
+		return errors.Wrap(err, "failed to load view dependency")
+	}
+	defer deps.Close()
+	for deps.Next() {
+		var d ViewDependency
+		if err := deps.Scan(&d.ViewName, &d.TargetSchema, &d.TargetTableName); err != nil {
+			return errors.Wrap(err, "failed to scan")
+		}
+		view, found := FindTableBySchemaName(tbls, schema, d.ViewName)
+		if !found || !view.IsView() {
+			continue
+		}
+		target, found := FindTableBySchemaName(tbls, d.TargetSchema, d.TargetTableName)
+		if !found {
+			continue
+		}
+		d.View = view
+		d.TargetTable = target
+		view.Dependencies = append(view.Dependencies, &d)
+	}
+	return deps.Err()
+}
+
+// ViewDependencyToUMLRelation dependency of views on tables in tbls
+func ViewDependencyToUMLRelation(tbls []*Table) ([]byte, error) {
+	tpl, err := template.New("dependency").Funcs(umlFuncMap(isMultiSchema(tbls))).Parse(dependencyTmpl)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[*Table]bool)
+	for _, tbl := range tbls {
+		rendered[tbl] = true
+	}
+	var src []byte
+	for _, tbl := range tbls {
+		for _, d := range tbl.Dependencies {
+			if !rendered[d.TargetTable] {
+				continue
+			}
+			buf := new(bytes.Buffer)
+			if err := tpl.Execute(buf, d); err != nil {
+				return nil, errors.Wrapf(err, "failed to execute template: %s", d.ViewName)
+			}
+			src = append(src, buf.Bytes()...)
+		}
+	}
+	return src, nil
+}
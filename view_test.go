@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestViewToPlantUML(t *testing.T) {
+	tbls := FilterTables(true, testTables(withViews), []string{"^customer$", "summary"})
+	buf, err := TableToPlantUML(tbls, "", LabelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+
+entity "**customer**" {
+  Customer Information
+  ..
+  + ""id"": //bigserial [PK]//
+  --
+  *""name"": //text  : Customer Name//
+  *""registered_at"": //timestamp with time zone //
+}
+
+entity "**customer_summary**" <<materialized view>> {
+  --
+  ""customer_id"": //bigint //
+  ""order_count"": //bigint //
+}
+
+"**customer_summary**" ..> "**customer**"
+@enduml
+`
+	if src := string(buf); src != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestViewSnapshot(t *testing.T) {
+	tbls := testTables(withViews)
+	buf, err := TableToJSON(tbls, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := loaded[len(loaded)-1]
+	if v.Kind != TableKindMaterializedView || !v.IsView() {
+		t.Errorf("want %s got %s", TableKindMaterializedView, v.Kind)
+	}
+	if len(v.Dependencies) != 2 || v.Dependencies[0].TargetTable != loaded[0] {
+		t.Errorf("dependencies are not resolved: %+v", v.Dependencies)
+	}
+}
+
+func TestLoadViewDef(t *testing.T) {
+	conn, cleanup := testPgSetup(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	schemas := []string{"public"}
+	cols, err := LoadSchemasColumnDefContext(ctx, conn, schemas, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := cols["public"]["customer_order_count"]; found {
+		t.Error("columns of view are loaded without views")
+	}
+	cols, err = LoadSchemasColumnDefContext(ctx, conn, schemas, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbls, err := LoadSchemasTableDefWithColumnsContext(ctx, conn, schemas, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	views, err := LoadViewDefContext(ctx, conn, schemas, tbls, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range views {
+		var names []string
+		for _, c := range v.Columns {
+			names = append(names, c.Name+" "+c.DataType)
+		}
+		var deps []string
+		for _, d := range v.Dependencies {
+			if target, _ := FindTableBySchemaName(tbls, "public", d.TargetTableName); d.TargetTable != target {
+				t.Errorf("%s: dependency on %s is not resolved", v.Name, d.TargetTableName)
+			}
+			deps = append(deps, d.TargetSchema+"."+d.TargetTableName)
+		}
+		got = append(got, fmt.Sprintf("%s %s %q (%s) -> %s", v.Kind, v.Name, v.Comment.String,
+			strings.Join(names, ", "), strings.Join(deps, ", ")))
+	}
+	expected := []string{
+		`view customer_order_count "Number of orders by customer" (customer_id bigint, order_count bigint) -> public.customer, public.customer_order`,
+		`materialized view sku_sales "" (sku_id bigint, amount numeric) -> public.order_detail`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("want\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}